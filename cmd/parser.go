@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime/debug"
+	"sort"
 
 	"golang.org/x/exp/slices"
 )
@@ -15,6 +17,41 @@ type CommandLineState struct {
 
 	// The function that returns the header (program name, version, etc.)
 	GetHeader func() string
+
+	// Input passed to commands, defaults to os.Stdin
+	In io.Reader
+
+	// Where usage and help are written and passed to commands, defaults to os.Stdout
+	Out io.Writer
+
+	// Where errors are written and passed to commands, defaults to os.Stderr
+	Err io.Writer
+}
+
+// IO is the input and outputs a command should use, passed to IOFunc
+type IO struct {
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// Returns the IO of the state with defaults filled in
+func (s *CommandLineState) IO() IO {
+	cio := IO{In: s.In, Out: s.Out, Err: s.Err}
+
+	if cio.In == nil {
+		cio.In = os.Stdin
+	}
+
+	if cio.Out == nil {
+		cio.Out = os.Stdout
+	}
+
+	if cio.Err == nil {
+		cio.Err = os.Stderr
+	}
+
+	return cio
 }
 
 // Helper method that returns the git commit hash
@@ -35,11 +72,37 @@ func GetGitCommit() string {
 
 type Command struct {
 	Func        func(progname string, args []string)
+	IOFunc      func(cio IO, progname string, args []string) // Like Func but receives the states IO, used over Func if set
 	Help        string
 	Usage       string
 	Example     string
 	Subcommands map[string]Command
 	ArgValidate func(args []string) error
+
+	// Hidden commands are not listed in help output but can still be run
+	Hidden bool
+
+	// Group the command is listed under in help output, ungrouped commands are listed under DefaultGroup
+	Group string
+
+	// Names of the positional arguments of the command, in order
+	//
+	// Only needed for EnvArgs and DefaultArgs
+	Args []string
+
+	// Maps argument names (from Args) to environment variables used when the argument is not passed
+	EnvArgs map[string]string
+
+	// Maps argument names (from Args) to default values used when the argument is not passed or in the environment
+	DefaultArgs map[string]string
+}
+
+// The group commands without a Group are listed under
+var DefaultGroup = "Other"
+
+// Returns whether the command itself can be run (as opposed to only having subcommands)
+func (c *Command) runnable() bool {
+	return c.Func != nil || c.IOFunc != nil
 }
 
 func (c *Command) Validate(args []string) error {
@@ -54,6 +117,36 @@ func (c *Command) Validate(args []string) error {
 	return nil
 }
 
+// FillArgs fills in missing positional arguments from the environment (EnvArgs) and defaults (DefaultArgs)
+//
+// Precedence is explicit argument > environment variable > default. Filling stops at the first
+// argument with no value, as later arguments would otherwise shift position
+func (c *Command) FillArgs(args []string) []string {
+	if len(args) >= len(c.Args) {
+		return args
+	}
+
+	filled := append([]string{}, args...)
+
+	for _, name := range c.Args[len(args):] {
+		if env, ok := c.EnvArgs[name]; ok {
+			if v := os.Getenv(env); v != "" {
+				filled = append(filled, v)
+				continue
+			}
+		}
+
+		if v, ok := c.DefaultArgs[name]; ok {
+			filled = append(filled, v)
+			continue
+		}
+
+		break
+	}
+
+	return filled
+}
+
 func FindCommandByArgs(cmds map[string]Command, args []string) (*Command, []string, error) {
 	if len(args) == 0 {
 		return nil, args, fmt.Errorf("no command provided")
@@ -61,12 +154,12 @@ func FindCommandByArgs(cmds map[string]Command, args []string) (*Command, []stri
 
 	c, ok := cmds[args[0]]
 	if !ok {
-		return nil, args, fmt.Errorf("unknown command: %s", args[0])
+		return nil, args, fmt.Errorf("unknown command: %s%s", args[0], suggestion(cmds, args[0]))
 	}
 
 	if c.Subcommands != nil {
 		if len(args) < 2 {
-			if c.Func != nil {
+			if c.runnable() {
 				return &c, args, nil
 			}
 
@@ -76,7 +169,7 @@ func FindCommandByArgs(cmds map[string]Command, args []string) (*Command, []stri
 		subcmd, ok := c.Subcommands[args[1]]
 
 		if !ok {
-			return &c, args, fmt.Errorf("unknown subcommand: %s", args[0]+" "+args[1])
+			return &c, args, fmt.Errorf("unknown subcommand: %s%s", args[0]+" "+args[1], suggestion(c.Subcommands, args[1]))
 		}
 
 		c = subcmd
@@ -85,7 +178,7 @@ func FindCommandByArgs(cmds map[string]Command, args []string) (*Command, []stri
 		if c.Subcommands != nil {
 			if len(args) > 0 {
 				return FindCommandByArgs(c.Subcommands, args)
-			} else if c.Func == nil {
+			} else if !c.runnable() {
 				return &c, args, fmt.Errorf("no subcommand provided")
 			}
 		}
@@ -110,7 +203,21 @@ func (c *Command) GetUsage() string {
 	if c.Subcommands != nil {
 		initial += "\n\nSubcommands:"
 
-		for k, cmd := range c.Subcommands {
+		names := make([]string, 0, len(c.Subcommands))
+
+		for k := range c.Subcommands {
+			names = append(names, k)
+		}
+
+		sort.Strings(names)
+
+		for _, k := range names {
+			cmd := c.Subcommands[k]
+
+			if cmd.Hidden {
+				continue
+			}
+
 			initial += fmt.Sprintf("\n%s: %s", k, cmd.Help)
 		}
 	}
@@ -119,62 +226,146 @@ func (c *Command) GetUsage() string {
 }
 
 func CmdListToArray(cmds map[string]Command) []string {
-	s := []string{"Commands:"}
+	groups := map[string][]string{}
+	grouped := false
+
 	for k, cmd := range cmds {
-		s = append(s, fmt.Sprint(k+": ", cmd.Help))
+		if cmd.Hidden {
+			continue
+		}
+
+		if cmd.Group != "" {
+			grouped = true
+		}
+
+		groups[cmd.Group] = append(groups[cmd.Group], k)
+	}
+
+	s := []string{"Commands:"}
+
+	// Without any groups, keep the flat listing
+	if !grouped {
+		names := groups[""]
+		sort.Strings(names)
+
+		for _, k := range names {
+			s = append(s, fmt.Sprint(k+": ", cmds[k].Help))
+		}
+
+		return s
+	}
+
+	groupNames := make([]string, 0, len(groups))
+
+	for g := range groups {
+		if g != "" {
+			groupNames = append(groupNames, g)
+		}
+	}
+
+	sort.Strings(groupNames)
+
+	// Ungrouped commands go last
+	if _, ok := groups[""]; ok {
+		groupNames = append(groupNames, "")
+	}
+
+	for _, g := range groupNames {
+		header := g
+
+		if header == "" {
+			header = DefaultGroup
+		}
+
+		s = append(s, "", header+":")
+
+		names := groups[g]
+		sort.Strings(names)
+
+		for _, k := range names {
+			s = append(s, fmt.Sprint("  "+k+": ", cmds[k].Help))
+		}
 	}
 
 	return s
 }
 
 func CmdList(cmds map[string]Command) {
+	cmdListTo(os.Stdout, cmds)
+}
+
+func cmdListTo(w io.Writer, cmds map[string]Command) {
 	for _, cmd := range CmdListToArray(cmds) {
-		fmt.Println(cmd)
+		fmt.Fprintln(w, cmd)
 	}
 }
 
+// Runs the command given by os.Args, exiting with status 1 if it could not be run
 func (s *CommandLineState) Run() {
-	progname := os.Args[0]
-	args := os.Args[1:]
+	if code := s.RunArgs(os.Args); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// RunArgs is like Run but takes the arguments (including the program name, as in os.Args) and returns
+// the exit status instead of exiting
+func (s *CommandLineState) RunArgs(osArgs []string) int {
+	var progname string
+	var args []string
+
+	if len(osArgs) > 0 {
+		progname = osArgs[0]
+		args = osArgs[1:]
+	}
+
+	cio := s.IO()
 
 	if len(args) == 0 {
-		fmt.Printf("usage: %s <command> [args]\n\n", progname)
-		CmdList(s.Commands)
-		os.Exit(1)
+		fmt.Fprintf(cio.Out, "usage: %s <command> [args]\n\n", progname)
+		cmdListTo(cio.Out, s.Commands)
+		return 1
 	}
 
 	cmd, args, err := FindCommandByArgs(s.Commands, args)
 
 	if slices.Contains(args, "-h") || slices.Contains(args, "--help") {
-		fmt.Printf("%s\n\n", s.GetHeader())
-		fmt.Printf("structure: %s <command> [args]\n\n", progname)
+		fmt.Fprintf(cio.Out, "%s\n\n", s.GetHeader())
+		fmt.Fprintf(cio.Out, "structure: %s <command> [args]\n\n", progname)
 
 		if cmd != nil {
-			fmt.Printf("%s\n\n", cmd.GetUsage())
+			fmt.Fprintf(cio.Out, "%s\n\n", cmd.GetUsage())
 		} else {
-			CmdList(s.Commands)
+			cmdListTo(cio.Out, s.Commands)
 		}
 
-		os.Exit(1)
+		return 1
 	}
 
 	if err != nil {
-		fmt.Printf("error: %s\n\n", err)
+		fmt.Fprintf(cio.Err, "error: %s\n\n", err)
 
 		if cmd != nil {
-			fmt.Printf("structure: %s [args]\n%s\n\n", progname, cmd.GetUsage())
+			fmt.Fprintf(cio.Out, "structure: %s [args]\n%s\n\n", progname, cmd.GetUsage())
 		} else {
-			CmdList(s.Commands)
+			cmdListTo(cio.Out, s.Commands)
 		}
 
-		os.Exit(1)
+		return 1
 	}
 
+	args = cmd.FillArgs(args)
+
 	if err := cmd.Validate(args); err != nil {
-		fmt.Printf("error: %s\n\n", err)
-		fmt.Printf("structure: %s [args]\n%s\n\n", progname, cmd.GetUsage())
-		os.Exit(1)
+		fmt.Fprintf(cio.Err, "error: %s\n\n", err)
+		fmt.Fprintf(cio.Out, "structure: %s [args]\n%s\n\n", progname, cmd.GetUsage())
+		return 1
+	}
+
+	if cmd.IOFunc != nil {
+		cmd.IOFunc(cio, progname, args)
+		return 0
 	}
 
 	cmd.Func(progname, args)
+	return 0
 }
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newTestState() (*CommandLineState, *bytes.Buffer, *bytes.Buffer) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+
+	return &CommandLineState{
+		GetHeader: func() string { return "test v1" },
+		Out:       out,
+		Err:       errOut,
+		In:        strings.NewReader("input"),
+		Commands: map[string]Command{
+			"greet": {
+				Help: "Greets someone",
+				IOFunc: func(cio IO, progname string, args []string) {
+					fmt.Fprintf(cio.Out, "hello %s\n", strings.Join(args, " "))
+					fmt.Fprintln(cio.Err, "greeted")
+				},
+				Args:        []string{"name"},
+				DefaultArgs: map[string]string{"name": "world"},
+				ArgValidate: func(args []string) error {
+					if len(args) > 0 && args[0] == "nobody" {
+						return errors.New("cannot greet nobody")
+					}
+
+					return nil
+				},
+			},
+		},
+	}, out, errOut
+}
+
+func TestRunArgs(t *testing.T) {
+	s, out, errOut := newTestState()
+
+	if code := s.RunArgs([]string{"prog", "greet", "alice"}); code != 0 {
+		t.Fatalf("got exit status %d, want 0", code)
+	}
+
+	if out.String() != "hello alice\n" || errOut.String() != "greeted\n" {
+		t.Fatalf("unexpected output %q, %q", out.String(), errOut.String())
+	}
+
+	out.Reset()
+
+	if code := s.RunArgs([]string{"prog", "greet"}); code != 0 || out.String() != "hello world\n" {
+		t.Fatalf("default arg not applied, got %d, %q", code, out.String())
+	}
+}
+
+func TestRunArgsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr []string
+	}{
+		{"no args", []string{"prog"}, []string{"usage: prog <command> [args]", "greet: Greets someone"}, nil},
+		{"help", []string{"prog", "greet", "--help"}, []string{"test v1", "Greets someone"}, nil},
+		{"unknown command", []string{"prog", "wave"}, []string{"greet: Greets someone"}, []string{"error: "}},
+		{"invalid args", []string{"prog", "greet", "nobody"}, []string{"structure: prog [args]"}, []string{"error: ", "cannot greet nobody"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, out, errOut := newTestState()
+
+			if code := s.RunArgs(tt.args); code != 1 {
+				t.Fatalf("got exit status %d, want 1", code)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output %q does not contain %q", out.String(), want)
+				}
+			}
+
+			for _, want := range tt.wantErr {
+				if !strings.Contains(errOut.String(), want) {
+					t.Errorf("error output %q does not contain %q", errOut.String(), want)
+				}
+			}
+
+			if len(tt.wantErr) == 0 && errOut.Len() != 0 {
+				t.Errorf("unexpected output to Err %q", errOut.String())
+			}
+
+			if strings.Contains(out.String(), "error: ") {
+				t.Errorf("error written to Out %q", out.String())
+			}
+		})
+	}
+}
+
+func TestHiddenCommand(t *testing.T) {
+	ran := false
+
+	cmds := map[string]Command{
+		"greet": {Help: "Greets someone", Func: func(progname string, args []string) {}},
+		"debug": {
+			Help:   "Internal debugging",
+			Hidden: true,
+			Func:   func(progname string, args []string) { ran = true },
+		},
+		"admin": {
+			Help: "Admin commands",
+			Subcommands: map[string]Command{
+				"users":  {Help: "Lists users", Func: func(progname string, args []string) {}},
+				"secret": {Help: "Secret admin command", Hidden: true, Func: func(progname string, args []string) { ran = true }},
+			},
+		},
+	}
+
+	for _, line := range CmdListToArray(cmds) {
+		if strings.Contains(line, "debug") {
+			t.Fatalf("hidden command listed: %q", line)
+		}
+	}
+
+	admin := cmds["admin"]
+	usage := admin.GetUsage()
+
+	if strings.Contains(usage, "secret") || !strings.Contains(usage, "users: Lists users") {
+		t.Fatalf("unexpected subcommand listing %q", usage)
+	}
+
+	for _, args := range [][]string{{"debug"}, {"admin", "secret"}} {
+		ran = false
+
+		cmd, rest, err := FindCommandByArgs(cmds, args)
+
+		if err != nil {
+			t.Fatalf("hidden command %v not found: %s", args, err)
+		}
+
+		cmd.Func("prog", rest)
+
+		if !ran {
+			t.Fatalf("hidden command %v was not run", args)
+		}
+	}
+}
+
+func TestCmdListToArrayGroups(t *testing.T) {
+	cmds := map[string]Command{
+		"migrate": {Help: "Runs migrations", Group: "Database"},
+		"backup":  {Help: "Backs up the database", Group: "Database"},
+		"deploy":  {Help: "Deploys a service", Group: "Ops"},
+		"version": {Help: "Prints the version"},
+		"about":   {Help: "About this tool"},
+	}
+
+	want := []string{
+		"Commands:",
+		"",
+		"Database:",
+		"  backup: Backs up the database",
+		"  migrate: Runs migrations",
+		"",
+		"Ops:",
+		"  deploy: Deploys a service",
+		"",
+		DefaultGroup + ":",
+		"  about: About this tool",
+		"  version: Prints the version",
+	}
+
+	got := CmdListToArray(cmds)
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestEnvArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		args []string
+		want []string
+	}{
+		{"from environment", "ci", nil, []string{"ci", "8080"}},
+		{"explicit overrides environment", "ci", []string{"alice"}, []string{"alice", "8080"}},
+		{"default without environment", "", nil, []string{"world", "8080"}},
+		{"all explicit", "ci", []string{"alice", "80"}, []string{"alice", "80"}},
+	}
+
+	cmd := Command{
+		Args:        []string{"name", "port"},
+		EnvArgs:     map[string]string{"name": "GREET_NAME"},
+		DefaultArgs: map[string]string{"name": "world", "port": "8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GREET_NAME", tt.env)
+
+			got := cmd.FillArgs(tt.args)
+
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillArgsStopsAtMissing(t *testing.T) {
+	cmd := Command{
+		Args:        []string{"name", "port"},
+		DefaultArgs: map[string]string{"port": "8080"},
+	}
+
+	if got := cmd.FillArgs(nil); len(got) != 0 {
+		t.Fatalf("got %q, want no arguments as name has no value", got)
+	}
+}
+
+func TestCmdListToArrayOrder(t *testing.T) {
+	cmds := map[string]Command{}
+
+	for _, name := range []string{"zeta", "alpha", "mu", "beta", "omega", "gamma"} {
+		cmds[name] = Command{Help: name}
+	}
+
+	want := "Commands:\nalpha: alpha\nbeta: beta\ngamma: gamma\nmu: mu\nomega: omega\nzeta: zeta"
+
+	// Map iteration order is randomized, so a few runs would catch unsorted output
+	for i := 0; i < 10; i++ {
+		if got := strings.Join(CmdListToArray(cmds), "\n"); got != want {
+			t.Fatalf("got\n%s\nwant the commands sorted", got)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The maximum edit distance for a command to be suggested
+var SuggestionThreshold = 2
+
+// Returns the levenshtein distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// Returns a `, did you mean "x"?` suffix for the closest command to name, or an empty string if none are close enough
+func suggestion(cmds map[string]Command, name string) string {
+	names := make([]string, 0, len(cmds))
+
+	for k, cmd := range cmds {
+		// Suggesting hidden commands would reveal them
+		if cmd.Hidden {
+			continue
+		}
+
+		names = append(names, k)
+	}
+
+	// Sort so ties are broken consistently
+	sort.Strings(names)
+
+	closest := ""
+	closestDist := SuggestionThreshold + 1
+
+	for _, k := range names {
+		dist := levenshtein(name, k)
+
+		// Don't suggest commands that share nothing with what was typed
+		if dist < closestDist && dist < len([]rune(name)) {
+			closest = k
+			closestDist = dist
+		}
+	}
+
+	if closest == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(`, did you mean "%s"?`, closest)
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"deploy", "deploy", 0},
+		{"deplyo", "deploy", 2},
+		{"héllo", "hello", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestUnknownCommandSuggestions(t *testing.T) {
+	cmds := map[string]Command{
+		"deploy": {
+			Help: "Deploys a service",
+			Subcommands: map[string]Command{
+				"status":   {Help: "Shows deploy status", Func: func(progname string, args []string) {}},
+				"rollback": {Help: "Rolls back a deploy", Func: func(progname string, args []string) {}},
+			},
+		},
+		"migrate": {Help: "Runs migrations", Func: func(progname string, args []string) {}},
+		"debug":   {Help: "Internal debugging", Hidden: true, Func: func(progname string, args []string) {}},
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"top level", []string{"deplyo"}, `unknown command: deplyo, did you mean "deploy"?`},
+		{"subcommand", []string{"deploy", "stauts"}, `unknown subcommand: deploy stauts, did you mean "status"?`},
+		{"too far", []string{"xyz"}, "unknown command: xyz"},
+		{"nothing in common", []string{"ab"}, "unknown command: ab"},
+		{"hidden not suggested", []string{"debgu"}, "unknown command: debgu"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := FindCommandByArgs(cmds, tt.args)
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if err.Error() != tt.want {
+				t.Fatalf("got %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
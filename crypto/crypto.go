@@ -1,35 +1,75 @@
 package crypto
 
 import (
-	"math/rand"
-	"time"
-	"unsafe"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 )
 
-const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 const (
-	letterIdxBits = 6                    // 6 bits to represent a letter index
-	letterIdxMask = 1<<letterIdxBits - 1 // All 1-bits, as many as letterIdxBits
-	letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
+	letterBytes  = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	hexBytes     = "0123456789abcdef"
+	urlSafeBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"
 )
 
-// https://stackoverflow.com/questions/22892120/how-to-generate-a-random-string-of-a-fixed-length-in-go
+// Returns a random string of length n consisting of ASCII letters
 func RandString(n int) string {
-	var src = rand.NewSource(time.Now().UnixNano())
+	return RandStringFrom(n, letterBytes)
+}
+
+// Returns a random lowercase hex string of length n
+func RandHex(n int) string {
+	return RandStringFrom(n, hexBytes)
+}
+
+// Returns a random string of length n that is safe for use in URLs (RFC 4648 base64url alphabet)
+func RandURLSafe(n int) string {
+	return RandStringFrom(n, urlSafeBytes)
+}
+
+// RandStringFrom returns a random string of length n with characters chosen uniformly from charset
+//
+// Randomness is read from crypto/rand and bytes that would bias the selection are rejected. The
+// charset must contain between 1 and 256 bytes
+func RandStringFrom(n int, charset string) string {
+	if len(charset) == 0 || len(charset) > 256 {
+		panic("crypto: charset must contain between 1 and 256 bytes")
+	}
+
+	// Largest multiple of len(charset) that fits in a byte, bytes at or above this are rejected
+	limit := 256 - (256 % len(charset))
 
 	b := make([]byte, n)
-	// A src.Int63() generates 63 random bits, enough for letterIdxMax characters!
-	for i, cache, remain := n-1, src.Int63(), letterIdxMax; i >= 0; {
-		if remain == 0 {
-			cache, remain = src.Int63(), letterIdxMax
+	buf := make([]byte, n+n/4+1)
+
+	for i := 0; i < n; {
+		if _, err := rand.Read(buf); err != nil {
+			panic("crypto: failed to read random bytes: " + err.Error())
 		}
-		if idx := int(cache & letterIdxMask); idx < len(letterBytes) {
-			b[i] = letterBytes[idx]
-			i--
+
+		for _, r := range buf {
+			if int(r) >= limit {
+				continue
+			}
+
+			b[i] = charset[int(r)%len(charset)]
+			i++
+
+			if i == n {
+				break
+			}
 		}
-		cache >>= letterIdxBits
-		remain--
 	}
 
-	return *(*string)(unsafe.Pointer(&b))
+	return string(b)
+}
+
+// SecureCompare compares two secrets (such as API tokens) in constant time
+//
+// Both inputs are hashed first so the comparison does not leak the length of either secret
+func SecureCompare(a, b string) bool {
+	ah := sha256.Sum256([]byte(a))
+	bh := sha256.Sum256([]byte(b))
+
+	return subtle.ConstantTimeCompare(ah[:], bh[:]) == 1
 }
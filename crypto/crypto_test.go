@@ -0,0 +1,90 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRandStringFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		gen     func(n int) string
+		charset string
+	}{
+		{"RandString", RandString, letterBytes},
+		{"RandHex", RandHex, hexBytes},
+		{"RandURLSafe", RandURLSafe, urlSafeBytes},
+		{"RandStringFrom", func(n int) string { return RandStringFrom(n, "abc") }, "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, n := range []int{0, 1, 12, 100} {
+				s := tt.gen(n)
+
+				if len(s) != n {
+					t.Fatalf("got length %d, want %d", len(s), n)
+				}
+
+				for _, c := range s {
+					if !strings.ContainsRune(tt.charset, c) {
+						t.Fatalf("%q is not in the charset %q", c, tt.charset)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRandStringFromDistribution(t *testing.T) {
+	// 3 does not divide 256, so a biased implementation would favour the first character
+	const charset = "abc"
+	const n = 30000
+
+	counts := map[rune]int{}
+
+	for _, c := range RandStringFrom(n, charset) {
+		counts[c]++
+	}
+
+	expected := n / len(charset)
+
+	for _, c := range charset {
+		// Allow 5% either side, far outside what chance should produce at this sample size
+		if diff := counts[c] - expected; diff > expected/20 || diff < -expected/20 {
+			t.Fatalf("%q appeared %d times, expected about %d", c, counts[c], expected)
+		}
+	}
+}
+
+func TestRandStringFromInvalidCharset(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for an empty charset")
+		}
+	}()
+
+	RandStringFrom(1, "")
+}
+
+func TestSecureCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{"equal", "s3cr3t-token", "s3cr3t-token", true},
+		{"both empty", "", "", true},
+		{"unequal same length", "s3cr3t-token", "s3cr3t-tokem", false},
+		{"unequal different length", "s3cr3t-token", "s3cr3t", false},
+		{"one empty", "s3cr3t-token", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SecureCompare(tt.a, tt.b); got != tt.want {
+				t.Fatalf("SecureCompare(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
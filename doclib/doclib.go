@@ -2,8 +2,10 @@ package doclib
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -330,6 +332,37 @@ func Route(doc *Doc) {
 		},
 	}
 
+	for status, example := range doc.Examples {
+		statusStr := strconv.Itoa(status)
+
+		resp, ok := operationData.Responses[statusStr]
+
+		if !ok {
+			respSchemaName := schemaName
+
+			if status >= 400 {
+				respSchemaName = DocsSetupData.errorStructName
+			}
+
+			resp = Response{
+				Description: http.StatusText(status),
+				Content: map[string]SchemaResp{
+					"application/json": {
+						Schema: Schema{
+							Ref: "#/components/schemas/" + respSchemaName,
+						},
+					},
+				},
+			}
+		}
+
+		content := resp.Content["application/json"]
+		content.Example = example
+		resp.Content["application/json"] = content
+
+		operationData.Responses[statusStr] = resp
+	}
+
 	if reqBodyRef != nil {
 		operationData.RequestBody = reqBodyRef
 	}
@@ -348,6 +381,11 @@ func Route(doc *Doc) {
 		})
 	}
 
+	// An empty requirement means no auth is also accepted
+	if doc.AuthOptional && len(doc.AuthType) > 0 {
+		operationData.Security = append(operationData.Security, map[string][]string{})
+	}
+
 	op, _ := api.Paths.Get(doc.Pattern)
 
 	switch strings.ToLower(doc.Method) {
@@ -61,7 +61,8 @@ type Schema struct {
 }
 
 type SchemaResp struct {
-	Schema Schema `json:"schema"`
+	Schema  Schema `json:"schema"`
+	Example any    `json:"example,omitempty"`
 }
 
 // Represents a openAPI response
@@ -130,6 +131,10 @@ type Doc struct {
 	Resp        any
 	RespName    string // Just in case resp cannot be used to derive the name
 	AuthType    []string
+	Examples    map[int]any // Example responses keyed by status code
+
+	// Whether the route can also be called without auth, emitted as an empty security requirement
+	AuthOptional bool
 }
 
 type WebhookDoc struct {
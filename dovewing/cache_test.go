@@ -0,0 +1,62 @@
+package dovewing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+	redishotcache "github.com/topicbotlist/eureka-port/hotcache/redis"
+)
+
+func TestBannerAndAccentColorCached(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	t.Cleanup(func() { rdb.Close() })
+
+	state := platformtest.NewState()
+	state.PlatformUserCache = redishotcache.RedisHotCache[dovetypes.PlatformUser]{Redis: rdb}
+
+	p := platformtest.New(
+		state,
+		&dovetypes.PlatformUser{ID: "1", Username: "banner", Banner: "https://cdn.example/banner.png", AccentColor: "#5865f2"},
+		&dovetypes.PlatformUser{ID: "2", Username: "plain"},
+	)
+
+	ctx := context.Background()
+
+	for _, id := range []string{"1", "2"} {
+		if _, err := dovewing.GetUser(ctx, id, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Served from redis this time
+	u, err := dovewing.GetUser(ctx, "1", p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.ExtraData["cache"] != "redis" {
+		t.Fatalf("user was not served from redis: %+v", u.ExtraData)
+	}
+
+	if u.Banner != "https://cdn.example/banner.png" || u.AccentColor != "#5865f2" {
+		t.Fatalf("banner and accent color lost in cache: %+v", u)
+	}
+
+	u, err = dovewing.GetUser(ctx, "2", p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Banner != "" || u.AccentColor != "" {
+		t.Fatalf("user without a banner or accent color got %q and %q", u.Banner, u.AccentColor)
+	}
+}
@@ -0,0 +1,105 @@
+package dovewing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+	"github.com/topicbotlist/eureka-port/hotcache"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type requestKey struct{}
+
+// Records the context of the last Set, failing it if the context is done as redis would
+type recordingCache struct {
+	hotcache.HotCache[dovetypes.PlatformUser]
+
+	setCtx context.Context
+}
+
+func (c *recordingCache) Set(ctx context.Context, key string, value *dovetypes.PlatformUser, expiry time.Duration) error {
+	c.setCtx = ctx
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return c.HotCache.Set(ctx, key, value, expiry)
+}
+
+// Cancels the request once the user has been fetched, before it is cached
+type cancellingPlatform struct {
+	*platformtest.MockPlatform
+
+	cancel context.CancelFunc
+}
+
+func (p *cancellingPlatform) GetUser(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
+	defer p.cancel()
+
+	return p.MockPlatform.GetUser(ctx, id)
+}
+
+func TestCacheWriteUsesRequestContext(t *testing.T) {
+	state := platformtest.NewState()
+	cache := &recordingCache{HotCache: state.PlatformUserCache}
+	state.PlatformUserCache = cache
+
+	p := platformtest.New(state, &dovetypes.PlatformUser{ID: "1", Username: "alice"})
+
+	ctx := context.WithValue(context.Background(), requestKey{}, "req-1")
+
+	if _, err := dovewing.GetUser(ctx, "1", p); err != nil {
+		t.Fatal(err)
+	}
+
+	if cache.setCtx == nil || cache.setCtx.Value(requestKey{}) != "req-1" {
+		t.Fatal("redis write did not use the request context")
+	}
+}
+
+func TestCacheWriteCancelled(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+
+	state := platformtest.NewState()
+	state.Logger = zap.New(core)
+	cache := &recordingCache{HotCache: state.PlatformUserCache}
+	state.PlatformUserCache = cache
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := &cancellingPlatform{
+		MockPlatform: platformtest.New(state, &dovetypes.PlatformUser{ID: "1", Username: "alice"}),
+		cancel:       cancel,
+	}
+
+	// A cancelled write is logged, the fetched user is still returned
+	u, err := dovewing.GetUser(ctx, "1", p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Username != "alice" {
+		t.Fatalf("got %+v", u)
+	}
+
+	if cache.setCtx == nil || cache.setCtx.Err() == nil {
+		t.Fatal("expected the redis write to see the cancelled request context")
+	}
+
+	if exists, _ := cache.Exists(context.Background(), "mock:1"); exists {
+		t.Fatal("cancelled write was cached")
+	}
+
+	if logs.FilterMessage("Failed to update redis cache").Len() != 1 {
+		t.Fatalf("expected the failed write to be logged, got %v", logs.All())
+	}
+}
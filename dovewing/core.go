@@ -4,23 +4,189 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"sync"
 	"time"
 
-	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
-	"github.com/topicbotlist/eureka-port/hotcache"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+	"github.com/topicbotlist/eureka-port/hotcache"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"golang.org/x/exp/slices"
 )
 
 type BaseState struct {
 	Logger            *zap.Logger
 	Context           context.Context
-	Pool              *pgxpool.Pool
+	Pool              *pgxpool.Pool // The internal user cache, optional. If nil, users are only cached in PlatformUserCache
 	PlatformUserCache hotcache.HotCache[dovetypes.PlatformUser]
 	Middlewares       []func(p Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error)
 	UserExpiryTime    time.Duration
+
+	// The level background cache refreshes are logged at, defaults to info
+	//
+	// Errors during refreshes are always logged at the error level
+	RefreshLogLevel zapcore.Level
+
+	// How long a users status is cached for before being refreshed, should be shorter than UserExpiryTime
+	//
+	// Only used for platforms implementing StatusPlatform, if zero, status is only refreshed with the profile
+	StatusExpiryTime time.Duration
+
+	// Cumulative statistics on where users were fetched from, see the dovewing/metrics package to export these
+	Stats Stats
+
+	// What to do when a middleware returns an error, defaults to MiddlewareErrorFail
+	MiddlewareErrorPolicy MiddlewareErrorPolicy
+
+	// If set, returns the avatar to use for users without one (or with the platforms default
+	// avatar, see DefaultAvatarDetector). Applied before middlewares
+	DefaultAvatar func(p Platform, u *dovetypes.PlatformUser) string
+
+	// Skips creating and migrating the cache tables in InitPlatform, for when the schema is managed
+	// through migrations (e.g. when the database user lacks DDL privileges)
+	//
+	// The tables must then match the schema in InitPlatform
+	SkipTableCreation bool
+
+	// Records the names of the middlewares that modified a user under ExtraData[MiddlewareTraceKey], for debugging
+	//
+	// Only middlewares wrapped with NamedMiddleware are recorded
+	TraceMiddlewares bool
+}
+
+// The ExtraData key the middleware trace is stored under, see BaseState.TraceMiddlewares
+const MiddlewareTraceKey = "_middleware_trace"
+
+// NamedMiddleware wraps a middleware so it is recorded in the middleware trace when it modifies a user
+func NamedMiddleware(name string, fn func(p Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error)) func(p Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+	return func(p Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+		if !p.GetState().TraceMiddlewares {
+			return fn(p, u)
+		}
+
+		before := copyUser(u)
+
+		mu, err := fn(p, u)
+
+		if err != nil || mu == nil {
+			return mu, err
+		}
+
+		if !reflect.DeepEqual(before, copyUser(mu)) {
+			if mu.ExtraData == nil {
+				mu.ExtraData = map[string]any{}
+			}
+
+			trace, _ := mu.ExtraData[MiddlewareTraceKey].([]string)
+			mu.ExtraData[MiddlewareTraceKey] = append(trace, name)
+		}
+
+		return mu, nil
+	}
+}
+
+// Copies a user, including its flags and extra data, so in place modifications can be detected
+func copyUser(u *dovetypes.PlatformUser) dovetypes.PlatformUser {
+	c := *u
+	c.Flags = append([]string(nil), u.Flags...)
+
+	if u.ExtraData != nil {
+		c.ExtraData = make(map[string]any, len(u.ExtraData))
+
+		for k, v := range u.ExtraData {
+			c.ExtraData[k] = v
+		}
+	}
+
+	return c
+}
+
+// DefaultAvatarDetector can be implemented by platforms whose users get a generated default avatar,
+// so BaseState.DefaultAvatar also replaces those
+type DefaultAvatarDetector interface {
+	IsDefaultAvatar(u *dovetypes.PlatformUser) bool
+}
+
+// Returns whether the user has no avatar or the platforms default avatar
+func hasDefaultAvatar(platform Platform, u *dovetypes.PlatformUser) bool {
+	if u.Avatar == "" {
+		return true
+	}
+
+	if detector, ok := platform.(DefaultAvatarDetector); ok {
+		return detector.IsDefaultAvatar(u)
+	}
+
+	return false
+}
+
+var (
+	// ErrUserNotFound is returned when the user does not exist on the platform
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrPlatformUnavailable is returned when the platform could not be reached or failed (e.g. a 5xx or ratelimit)
+	//
+	// Unlike ErrUserNotFound, retrying later may succeed
+	ErrPlatformUnavailable = errors.New("platform unavailable")
+)
+
+// Controls how errors returned by middlewares are handled
+type MiddlewareErrorPolicy int
+
+const (
+	// Abort the fetch and return the middleware's error
+	MiddlewareErrorFail MiddlewareErrorPolicy = iota
+	// Log the error and continue with the user as it was before the failing middleware
+	MiddlewareErrorSkip
+)
+
+// PlatformStats contains cumulative counters for a single platform
+type PlatformStats struct {
+	RedisHits           uint64 // Users served from the redis cache
+	PostgresHits        uint64 // Users served from the internal user cache
+	PlatformFetches     uint64 // Users fetched from the platform itself
+	BackgroundRefreshes uint64 // Expired users refreshed in the background
+}
+
+// Stats contains cumulative counters for each platform using a base state
+type Stats struct {
+	mu        sync.Mutex
+	platforms map[string]*PlatformStats
+}
+
+func (s *Stats) record(platformName string, f func(ps *PlatformStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.platforms == nil {
+		s.platforms = make(map[string]*PlatformStats)
+	}
+
+	ps, ok := s.platforms[platformName]
+
+	if !ok {
+		ps = &PlatformStats{}
+		s.platforms[platformName] = ps
+	}
+
+	f(ps)
+}
+
+// Returns a copy of the current counters keyed by platform name
+func (s *Stats) Snapshot() map[string]PlatformStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]PlatformStats, len(s.platforms))
+
+	for name, ps := range s.platforms {
+		snapshot[name] = *ps
+	}
+
+	return snapshot
 }
 
 type Platform interface {
@@ -42,12 +208,89 @@ type Platform interface {
 	PlatformSpecificCache(ctx context.Context, id string) (*dovetypes.PlatformUser, error)
 	// fetch a user from the platform, at this point, assume that cache has been checked
 	GetUser(ctx context.Context, id string) (*dovetypes.PlatformUser, error)
+	// reports whether the platform is able to serve users, embed NoHealthCheck if not needed
+	HealthCheck(ctx context.Context) error
+}
+
+// StatusPlatform is implemented by platforms that can fetch a users status without fetching their full profile
+type StatusPlatform interface {
+	Platform
+	// fetch the status of a user, should not fetch the users profile
+	GetStatus(ctx context.Context, id string) (dovetypes.PlatformStatus, error)
+}
+
+// NoHealthCheck can be embedded in platforms that have nothing to health check
+type NoHealthCheck struct{}
+
+func (NoHealthCheck) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// Returns the health of a platform, nil if healthy
+func PlatformHealth(ctx context.Context, platform Platform) error {
+	return platform.HealthCheck(ctx)
+}
+
+// AliasPlatform is implemented by platforms that can also look up users by something other than their ID (e.g. a login)
+//
+// Aliases are resolved to the users ID before the caches are checked, so users are only ever cached under their ID
+type AliasPlatform interface {
+	Platform
+	// reports whether id is an alias rather than a user ID
+	IsAlias(id string) bool
+}
+
+// Returns the redis key the user ID of an alias is cached under
+func aliasKey(platform Platform, alias string) string {
+	return platform.PlatformName() + ":alias:" + alias
+}
+
+// Resolves id to a user ID if it is an alias, using the alias cache
+//
+// ok is false if id is an alias that is not cached, in which case only the platform can resolve it
+func resolveCachedAlias(ctx context.Context, id string, platform Platform) (resolved string, ok bool, err error) {
+	ap, isAliasPlatform := platform.(AliasPlatform)
+
+	if !isAliasPlatform || !ap.IsAlias(id) {
+		return id, true, nil
+	}
+
+	u, err := platform.GetState().PlatformUserCache.Get(ctx, aliasKey(platform, id))
+
+	if errors.Is(err, hotcache.ErrHotCacheDataNotFound) {
+		return "", false, nil
+	}
+
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get alias from redis cache: %s", err)
+	}
+
+	return u.ID, true, nil
+}
+
+// Caches the user ID an alias resolved to, a no-op if id is the users ID
+func cacheAlias(ctx context.Context, platform Platform, id string, u *dovetypes.PlatformUser) {
+	if id == u.ID {
+		return
+	}
+
+	state := platform.GetState()
+
+	err := state.PlatformUserCache.Set(ctx, aliasKey(platform, id), &dovetypes.PlatformUser{ID: u.ID}, state.UserExpiryTime)
+
+	if err != nil {
+		state.Logger.Warn("Failed to cache alias", zap.Error(err), zap.String("alias", id), zap.String("id", u.ID), zap.String("platform", platform.PlatformName()))
+	}
 }
 
 // Common platform init code
 func InitPlatform(platform Platform) error {
 	state := platform.GetState()
 
+	if state.SkipTableCreation || state.Pool == nil {
+		return platform.Init()
+	}
+
 	var tableName = TableName(platform)
 
 	_, err := state.Pool.Exec(state.Context, `
@@ -57,6 +300,8 @@ func InitPlatform(platform Platform) error {
 			display_name TEXT NOT NULL,
 			avatar TEXT NOT NULL,
 			bot BOOLEAN NOT NULL,
+			banner TEXT NOT NULL DEFAULT '',
+			accent_color TEXT NOT NULL DEFAULT '',
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			last_updated TIMESTAMPTZ NOT NULL DEFAULT NOW()
 		)
@@ -66,6 +311,17 @@ func InitPlatform(platform Platform) error {
 		return err
 	}
 
+	// Add columns added after the table was first created
+	_, err = state.Pool.Exec(state.Context, `
+		ALTER TABLE `+tableName+`
+			ADD COLUMN IF NOT EXISTS banner TEXT NOT NULL DEFAULT '',
+			ADD COLUMN IF NOT EXISTS accent_color TEXT NOT NULL DEFAULT ''
+	`)
+
+	if err != nil {
+		return err
+	}
+
 	return platform.Init()
 }
 
@@ -74,58 +330,207 @@ func TableName(platform Platform) string {
 	return "internal_user_cache__" + platform.PlatformName()
 }
 
-// Fetches a user based on the platform
-func GetUser(ctx context.Context, id string, platform Platform) (*dovetypes.PlatformUser, error) {
-	state := platform.GetState()
-
+// Inits the platform if it has not already been initted
+func ensureInit(platform Platform) error {
 	if !platform.Initted() {
 		// call InitPlatform first
 		err := InitPlatform(platform)
 
 		if err != nil {
-			return nil, errors.New("failed to init platform: " + err.Error())
+			return errors.New("failed to init platform: " + err.Error())
 		}
 
 		if !platform.Initted() {
-			return nil, errors.New("platform init() did not set initted() to true")
+			return errors.New("platform init() did not set initted() to true")
 		}
 	}
 
-	var platformName = platform.PlatformName()
-	var tableName = TableName(platform)
+	return nil
+}
 
-	// Common cacher, applicable to all use cases
-	cachedReturn := func(u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
-		if u == nil {
-			return nil, errors.New("user not found")
-		}
+// Common cacher, applicable to all use cases
+//
+// Both caches are keyed by the ID of the user, if id is an alias of the user it is cached as such
+func cachedReturn(ctx context.Context, platform Platform, id string, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+	u, err := prepareUser(platform, id, u)
 
-		if u.DisplayName == "" {
-			u.DisplayName = u.Username
+	if err != nil {
+		return nil, err
+	}
+
+	state := platform.GetState()
+
+	// Update cache
+	if state.Pool != nil {
+		_, err = state.Pool.Exec(state.Context, "INSERT INTO "+TableName(platform)+" (id, username, display_name, avatar, bot, banner, accent_color) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (id) DO UPDATE SET username = $2, display_name = $3, avatar = $4, bot = $5, banner = $6, accent_color = $7, last_updated = NOW()", u.ID, u.Username, u.DisplayName, u.Avatar, u.Bot, u.Banner, u.AccentColor)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to update internal user cache: %s", err)
 		}
+	}
 
-		var err error
+	// The user is already in the internal user cache, so a failed (e.g. cancelled) redis write is not fatal
+	err = state.PlatformUserCache.Set(ctx, platform.PlatformName()+":"+u.ID, u, state.UserExpiryTime)
 
-		for i, middleware := range state.Middlewares {
-			u, err = middleware(platform, u)
+	if err != nil {
+		state.Logger.Warn("Failed to update redis cache", zap.Error(err), zap.String("id", u.ID), zap.String("platform", platform.PlatformName()))
+	}
 
-			if err != nil {
-				return nil, fmt.Errorf("middleware %d failed: %s", i, err)
+	cacheAlias(ctx, platform, id, u)
+
+	return u, nil
+}
+
+// Fills in defaults and runs the middlewares on a user before it is cached
+func prepareUser(platform Platform, id string, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+
+	state := platform.GetState()
+
+	if u.ID == "" {
+		u.ID = id
+	}
+
+	if u.DisplayName == "" {
+		u.DisplayName = u.Username
+	}
+
+	if state.DefaultAvatar != nil && hasDefaultAvatar(platform, u) {
+		u.Avatar = state.DefaultAvatar(platform, u)
+	}
+
+	for i, middleware := range state.Middlewares {
+		mu, err := middleware(platform, u)
+
+		if err != nil {
+			if state.MiddlewareErrorPolicy == MiddlewareErrorSkip {
+				state.Logger.Error("Middleware failed, skipping", zap.Error(err), zap.Int("middleware", i), zap.String("id", id), zap.String("platform", platform.PlatformName()))
+				continue
 			}
+
+			return nil, fmt.Errorf("middleware %d failed: %s", i, err)
+		}
+
+		u = mu
+	}
+
+	return u, nil
+}
+
+// Caches many prepared users (see prepareUser) at once, keyed by user ID
+//
+// The internal user cache is updated with a single statement and the redis cache with a single SetMany
+func cacheUsers(ctx context.Context, platform Platform, users map[string]*dovetypes.PlatformUser) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	state := platform.GetState()
+
+	if state.Pool != nil {
+		var ids, usernames, displayNames, avatars, banners, accentColors []string
+		var bots []bool
+
+		// Users are keyed by user ID, so no row is upserted twice (which postgres rejects)
+		for _, u := range users {
+			ids = append(ids, u.ID)
+			usernames = append(usernames, u.Username)
+			displayNames = append(displayNames, u.DisplayName)
+			avatars = append(avatars, u.Avatar)
+			bots = append(bots, u.Bot)
+			banners = append(banners, u.Banner)
+			accentColors = append(accentColors, u.AccentColor)
 		}
 
-		// Update cache
-		_, err = state.Pool.Exec(state.Context, "INSERT INTO "+tableName+" (id, username, display_name, avatar, bot) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO UPDATE SET username = $2, display_name = $3, avatar = $4, bot = $5, last_updated = NOW()", u.ID, u.Username, u.DisplayName, u.Avatar, u.Bot)
+		_, err := state.Pool.Exec(state.Context, "INSERT INTO "+TableName(platform)+" (id, username, display_name, avatar, bot, banner, accent_color) SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::boolean[], $6::text[], $7::text[]) ON CONFLICT (id) DO UPDATE SET username = EXCLUDED.username, display_name = EXCLUDED.display_name, avatar = EXCLUDED.avatar, bot = EXCLUDED.bot, banner = EXCLUDED.banner, accent_color = EXCLUDED.accent_color, last_updated = NOW()", ids, usernames, displayNames, avatars, bots, banners, accentColors)
 
 		if err != nil {
-			return nil, fmt.Errorf("failed to update internal user cache: %s", err)
+			return fmt.Errorf("failed to update internal user cache: %s", err)
 		}
+	}
+
+	values := make(map[string]*dovetypes.PlatformUser, len(users))
 
-		state.PlatformUserCache.Set(state.Context, platformName+":"+id, u, state.UserExpiryTime)
+	for _, u := range users {
+		values[platform.PlatformName()+":"+u.ID] = u
+	}
 
-		return u, nil
+	err := hotcache.SetMany(ctx, state.PlatformUserCache, values, state.UserExpiryTime)
+
+	if err != nil {
+		state.Logger.Warn("Failed to update redis cache", zap.Error(err), zap.Int("users", len(users)), zap.String("platform", platform.PlatformName()))
 	}
 
+	return nil
+}
+
+// Refetches an expired user from the platform and updates the caches, run in the background by GetUser
+func refreshUser(platform Platform, id string) {
+	state := platform.GetState()
+	platformName := platform.PlatformName()
+
+	// Get from platform
+	state.Logger.Log(state.RefreshLogLevel, "Updating expired user cache", zap.String("id", id), zap.String("platform", platformName))
+
+	state.Stats.record(platformName, func(ps *PlatformStats) {
+		ps.BackgroundRefreshes++
+		ps.PlatformFetches++
+	})
+
+	// The request context may be cancelled before the refresh finishes
+	user, err := platform.GetUser(state.Context, id)
+
+	if err != nil {
+		state.Logger.Error("Failed to update expired user cache", zap.Error(err))
+		return
+	}
+
+	cachedReturn(state.Context, platform, id, &dovetypes.PlatformUser{
+		ID:          id,
+		Username:    user.Username,
+		Avatar:      user.Avatar,
+		DisplayName: user.DisplayName,
+		Bot:         user.Bot,
+		Status:      user.Status,
+		Banner:      user.Banner,
+		AccentColor: user.AccentColor,
+	})
+}
+
+// Fetches a user based on the platform
+func GetUser(ctx context.Context, id string, platform Platform) (*dovetypes.PlatformUser, error) {
+	state := platform.GetState()
+
+	if err := ensureInit(platform); err != nil {
+		return nil, err
+	}
+
+	var platformName = platform.PlatformName()
+	var tableName = TableName(platform)
+
+	// Resolve aliases first so the caches below are only checked by user ID
+	resolved, ok, err := resolveCachedAlias(ctx, id, platform)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		state.Stats.record(platformName, func(ps *PlatformStats) { ps.PlatformFetches++ })
+
+		user, err := platform.GetUser(ctx, id)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user from platform: %w", err)
+		}
+
+		return cachedReturn(ctx, platform, id, user)
+	}
+
+	id = resolved
+
 	// First, check platform specific cache
 	uCached, err := platform.PlatformSpecificCache(ctx, id)
 
@@ -134,7 +539,7 @@ func GetUser(ctx context.Context, id string, platform Platform) (*dovetypes.Plat
 	}
 
 	if uCached != nil {
-		return cachedReturn(uCached)
+		return cachedReturn(ctx, platform, id, uCached)
 	}
 
 	// Check if in redis cache
@@ -145,16 +550,55 @@ func GetUser(ctx context.Context, id string, platform Platform) (*dovetypes.Plat
 	}
 
 	if err == nil {
-		user.ExtraData = map[string]any{
-			"cache": "redis",
+		state.Stats.record(platformName, func(ps *PlatformStats) { ps.RedisHits++ })
+
+		// Keep what was cached with the user (e.g. the middleware trace)
+		if user.ExtraData == nil {
+			user.ExtraData = map[string]any{}
 		}
+
+		// Decoded from JSON as []any, restore the type set by the middlewares
+		if trace, ok := user.ExtraData[MiddlewareTraceKey].([]any); ok {
+			names := make([]string, 0, len(trace))
+
+			for _, name := range trace {
+				if name, ok := name.(string); ok {
+					names = append(names, name)
+				}
+			}
+
+			user.ExtraData[MiddlewareTraceKey] = names
+		}
+
+		user.ExtraData["cache"] = "redis"
+
+		if _, ok := platform.(StatusPlatform); ok && state.StatusExpiryTime > 0 {
+			status, err := state.PlatformUserCache.Get(ctx, platformName+":status:"+id)
+
+			if err == nil {
+				user.Status = status.Status
+			} else {
+				refreshed, err := RefreshStatus(ctx, id, platform)
+
+				if err != nil {
+					state.Logger.Warn("Failed to refresh user status", zap.Error(err), zap.String("id", id), zap.String("platform", platformName))
+				} else {
+					user.Status = refreshed
+				}
+			}
+		}
+
 		return user, nil
 	}
 
 	// Check if in internal user cache, this allows fetches of users not in cache to be done in the background
 	var count int64
 
-	err = state.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM "+tableName+" WHERE id = $1", id).Scan(&count)
+	if state.Pool == nil {
+		err = pgx.ErrNoRows
+	} else {
+		err = state.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM "+tableName+" WHERE id = $1", id).Scan(&count)
+	}
 
 	if errors.Is(err, pgx.ErrNoRows) {
 		count = 0
@@ -175,46 +619,33 @@ func GetUser(ctx context.Context, id string, platform Platform) (*dovetypes.Plat
 
 		if time.Since(lastUpdated) > state.UserExpiryTime {
 			// Update in background, since this is in cache, users won't mind this but will mind timeouts
-			go func() {
-				// Get from platform
-				state.Logger.Info("Updating expired user cache", zap.String("id", id), zap.String("platform", platformName))
-
-				user, err := platform.GetUser(ctx, id)
-
-				if err != nil {
-					state.Logger.Error("Failed to update expired user cache", zap.Error(err))
-					return
-				}
-
-				cachedReturn(&dovetypes.PlatformUser{
-					ID:          id,
-					Username:    user.Username,
-					Avatar:      user.Avatar,
-					DisplayName: user.DisplayName,
-					Bot:         user.Bot,
-					Status:      user.Status,
-				})
-			}()
+			go refreshUser(platform, id)
 		}
 
+		state.Stats.record(platformName, func(ps *PlatformStats) { ps.PostgresHits++ })
+
 		var username string
 		var avatar string
 		var bot bool
 		var createdAt time.Time
 		var displayName string
+		var banner string
+		var accentColor string
 
-		err = state.Pool.QueryRow(ctx, "SELECT username, display_name, avatar, bot, created_at FROM "+tableName+" WHERE id = $1", id).Scan(&username, &displayName, &avatar, &bot, &createdAt)
+		err = state.Pool.QueryRow(ctx, "SELECT username, display_name, avatar, bot, banner, accent_color, created_at FROM "+tableName+" WHERE id = $1", id).Scan(&username, &displayName, &avatar, &bot, &banner, &accentColor, &createdAt)
 
 		if err != nil {
 			return nil, err
 		}
 
-		return cachedReturn(&dovetypes.PlatformUser{
+		return cachedReturn(ctx, platform, id, &dovetypes.PlatformUser{
 			ID:          id,
 			Username:    username,
 			Avatar:      avatar,
 			DisplayName: displayName,
 			Bot:         bot,
+			Banner:      banner,
+			AccentColor: accentColor,
 			Status:      dovetypes.PlatformStatusOffline,
 			ExtraData: map[string]any{
 				"cache": "pg",
@@ -223,13 +654,80 @@ func GetUser(ctx context.Context, id string, platform Platform) (*dovetypes.Plat
 	}
 
 	// Get from platform
+	state.Stats.record(platformName, func(ps *PlatformStats) { ps.PlatformFetches++ })
+
 	user, err = platform.GetUser(ctx, id)
 
 	if err != nil {
-		return nil, errors.New("failed to get user from platform: " + err.Error())
+		return nil, fmt.Errorf("failed to get user from platform: %w", err)
+	}
+
+	return cachedReturn(ctx, platform, id, user)
+}
+
+// RefreshStatus refreshes only the status of a user, updating the redis copy of the user without refetching their profile
+//
+// The platform must implement StatusPlatform. The fetched status is cached for StatusExpiryTime
+func RefreshStatus(ctx context.Context, id string, platform Platform) (dovetypes.PlatformStatus, error) {
+	sp, ok := platform.(StatusPlatform)
+
+	if !ok {
+		return "", errors.New("platform does not support fetching statuses")
+	}
+
+	if err := ensureInit(platform); err != nil {
+		return "", err
+	}
+
+	state := platform.GetState()
+	platformName := platform.PlatformName()
+
+	status, err := sp.GetStatus(ctx, id)
+
+	if err != nil {
+		return "", err
+	}
+
+	expiry := state.StatusExpiryTime
+
+	if expiry == 0 {
+		expiry = state.UserExpiryTime
+	}
+
+	err = state.PlatformUserCache.Set(ctx, platformName+":status:"+id, &dovetypes.PlatformUser{ID: id, Status: status}, expiry)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to cache status: %s", err)
+	}
+
+	// Update the redis copy of the user, keeping its existing expiry so the profile is still refreshed on time
+	user, err := state.PlatformUserCache.Get(ctx, platformName+":"+id)
+
+	if errors.Is(err, hotcache.ErrHotCacheDataNotFound) {
+		return status, nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get user from redis cache: %s", err)
+	}
+
+	ttl, err := state.PlatformUserCache.Expiry(ctx, platformName+":"+id)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get user expiry from redis cache: %s", err)
+	}
+
+	if ttl > 0 {
+		user.Status = status
+
+		err = state.PlatformUserCache.Set(ctx, platformName+":"+id, user, ttl)
+
+		if err != nil {
+			return "", fmt.Errorf("failed to update user in redis cache: %s", err)
+		}
 	}
 
-	return cachedReturn(user)
+	return status, nil
 }
 
 type ClearFrom string
@@ -261,26 +759,38 @@ type ClearUserReq struct {
 func ClearUser(ctx context.Context, id string, platform Platform, req ClearUserReq) (*ClearUserInfo, error) {
 	state := platform.GetState()
 
-	if !platform.Initted() {
-		// call InitPlatform first
-		err := InitPlatform(platform)
+	if err := ensureInit(platform); err != nil {
+		return nil, err
+	}
+
+	var platformName = platform.PlatformName()
+	var tableName = TableName(platform)
+
+	// Aliases that are not cached have nothing else cached under them either
+	resolved, ok, err := resolveCachedAlias(ctx, id, platform)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return &ClearUserInfo{}, nil
+	}
+
+	if resolved != id {
+		err = state.PlatformUserCache.Delete(ctx, aliasKey(platform, id))
 
 		if err != nil {
-			return nil, errors.New("failed to init platform: " + err.Error())
+			return nil, err
 		}
 
-		if !platform.Initted() {
-			return nil, errors.New("platform init() did not set initted() to true")
-		}
+		id = resolved
 	}
 
-	var platformName = platform.PlatformName()
-	var tableName = TableName(platform)
-
 	var clearedFrom []ClearFrom
 
 	// Check iuc
-	if len(req.ClearFrom) == 0 || slices.Contains(req.ClearFrom, ClearFromInternalUserCache) {
+	if state.Pool != nil && (len(req.ClearFrom) == 0 || slices.Contains(req.ClearFrom, ClearFromInternalUserCache)) {
 		var count int64
 
 		err := state.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM "+tableName+" WHERE id = $1", id).Scan(&count)
@@ -310,6 +820,12 @@ func ClearUser(ctx context.Context, id string, platform Platform, req ClearUserR
 			return nil, err
 		}
 
+		err = state.PlatformUserCache.Delete(ctx, platformName+":status:"+id)
+
+		if err != nil {
+			return nil, err
+		}
+
 		clearedFrom = append(clearedFrom, ClearFromRedis) // TODO: make this a constant
 	}
 
@@ -317,3 +833,122 @@ func ClearUser(ctx context.Context, id string, platform Platform, req ClearUserR
 		ClearedFrom: clearedFrom,
 	}, nil
 }
+
+// Returns whether a user is already fresh in either the redis cache or the internal user cache
+func isCacheFresh(ctx context.Context, id string, platform Platform) (bool, error) {
+	state := platform.GetState()
+
+	exists, err := state.PlatformUserCache.Exists(ctx, platform.PlatformName()+":"+id)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check redis cache: %s", err)
+	}
+
+	if exists || state.Pool == nil {
+		return exists, nil
+	}
+
+	var lastUpdated time.Time
+
+	err = state.Pool.QueryRow(ctx, "SELECT last_updated FROM "+TableName(platform)+" WHERE id = $1", id).Scan(&lastUpdated)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check internal user cache: %s", err)
+	}
+
+	return time.Since(lastUpdated) <= state.UserExpiryTime, nil
+}
+
+// The number of users WarmCache fetches before writing them to the caches at once
+const warmCacheBatchSize = 100
+
+// WarmCache pre-populates the caches of a platform with the given users, returning the number of users fetched
+//
+// Users that are already fresh in cache are skipped. Users are fetched one at a time to avoid
+// tripping platform ratelimits and are written to the caches in batches. Users that fail to be
+// fetched are logged and skipped
+func WarmCache(ctx context.Context, ids []string, platform Platform) (warmed int, err error) {
+	if err := ensureInit(platform); err != nil {
+		return 0, err
+	}
+
+	state := platform.GetState()
+	batch := make(map[string]*dovetypes.PlatformUser, warmCacheBatchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		// Not the request context, the users have already been fetched
+		if err := cacheUsers(state.Context, platform, batch); err != nil {
+			state.Logger.Warn("Failed to cache users while warming cache", zap.Error(err), zap.Int("users", len(batch)), zap.String("platform", platform.PlatformName()))
+		} else {
+			warmed += len(batch)
+		}
+
+		batch = make(map[string]*dovetypes.PlatformUser, warmCacheBatchSize)
+	}
+
+	defer flush()
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return warmed, ctx.Err()
+		}
+
+		// Aliases that are not cached must be fetched to find the user ID
+		resolved, ok, err := resolveCachedAlias(ctx, id, platform)
+
+		if err != nil {
+			return warmed, err
+		}
+
+		if ok {
+			if _, ok := batch[resolved]; ok {
+				continue
+			}
+
+			fresh, err := isCacheFresh(ctx, resolved, platform)
+
+			if err != nil {
+				return warmed, err
+			}
+
+			if fresh {
+				continue
+			}
+
+			id = resolved
+		}
+
+		state.Stats.record(platform.PlatformName(), func(ps *PlatformStats) { ps.PlatformFetches++ })
+
+		user, err := platform.GetUser(ctx, id)
+
+		if err != nil {
+			state.Logger.Warn("Failed to fetch user while warming cache", zap.Error(err), zap.String("id", id), zap.String("platform", platform.PlatformName()))
+			continue
+		}
+
+		user, err = prepareUser(platform, id, user)
+
+		if err != nil {
+			state.Logger.Warn("Failed to cache user while warming cache", zap.Error(err), zap.String("id", id), zap.String("platform", platform.PlatformName()))
+			continue
+		}
+
+		cacheAlias(ctx, platform, id, user)
+		batch[user.ID] = user
+
+		if len(batch) >= warmCacheBatchSize {
+			flush()
+		}
+	}
+
+	return warmed, nil
+}
@@ -0,0 +1,546 @@
+package dovewing_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+	redishotcache "github.com/topicbotlist/eureka-port/hotcache/redis"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWarmCacheBatches(t *testing.T) {
+	var users []*dovetypes.PlatformUser
+	var ids []string
+
+	// More than one batch, with a duplicate id
+	for i := 0; i < 250; i++ {
+		id := strconv.Itoa(1000 + i)
+		users = append(users, &dovetypes.PlatformUser{ID: id, Username: "user" + id})
+		ids = append(ids, id)
+	}
+
+	ids = append(ids, "1000")
+
+	p := platformtest.New(nil, users...)
+	ctx := context.Background()
+
+	warmed, err := dovewing.WarmCache(ctx, ids, p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if warmed != 250 {
+		t.Fatalf("warmed %d users, want 250", warmed)
+	}
+
+	for _, u := range users {
+		cached, err := p.GetState().PlatformUserCache.Get(ctx, "mock:"+u.ID)
+
+		if err != nil {
+			t.Fatalf("%s not cached: %s", u.ID, err)
+		}
+
+		if cached.ID != u.ID || cached.DisplayName != u.Username {
+			t.Fatalf("%s: unexpected cached user %+v", u.ID, cached)
+		}
+
+		if calls := p.Calls(u.ID); calls != 1 {
+			t.Fatalf("%s fetched %d times", u.ID, calls)
+		}
+	}
+
+	// Everything is fresh now
+	warmed, err = dovewing.WarmCache(ctx, ids, p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if warmed != 0 {
+		t.Fatalf("rewarmed %d fresh users", warmed)
+	}
+}
+
+func TestWarmCacheAliasesShareID(t *testing.T) {
+	var requests int64
+
+	srv := newGitHubServer(t, &requests)
+	state := platformtest.NewState()
+
+	gh, err := dovewing.GitHubStateConfig{BaseURL: srv.URL, BaseState: state}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	warmed, err := dovewing.WarmCache(ctx, []string{"octocat", "583231", "octocat"}, gh)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if warmed != 1 || requests != 1 {
+		t.Fatalf("warmed %d users with %d requests, want 1 and 1", warmed, requests)
+	}
+
+	if exists, _ := state.PlatformUserCache.Exists(ctx, "github:583231"); !exists {
+		t.Fatal("user not cached under ID")
+	}
+
+	// The warmed alias is served from cache
+	if _, err := dovewing.GetUser(ctx, "octocat", gh); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 1 {
+		t.Fatalf("warmed alias was refetched, %d requests", requests)
+	}
+}
+
+func TestWarmCacheSkipsFresh(t *testing.T) {
+	state := platformtest.NewState()
+	state.UserExpiryTime = 100 * time.Millisecond
+
+	p := platformtest.New(
+		state,
+		&dovetypes.PlatformUser{ID: "1", Username: "stale"},
+		&dovetypes.PlatformUser{ID: "2", Username: "fresh"},
+		&dovetypes.PlatformUser{ID: "3", Username: "missing"},
+	)
+
+	ctx := context.Background()
+
+	if _, err := dovewing.WarmCache(ctx, []string{"1", "2"}, p); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	// Refresh 2 so that only 1 is stale, and rename 1 to check the stale entry is refetched
+	if _, err := dovewing.GetUser(ctx, "2", p); err != nil {
+		t.Fatal(err)
+	}
+
+	p.SetUser(&dovetypes.PlatformUser{ID: "1", Username: "renamed"})
+
+	warmed, err := dovewing.WarmCache(ctx, []string{"1", "2", "3"}, p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if warmed != 2 {
+		t.Fatalf("warmed %d users, want 2", warmed)
+	}
+
+	for id, want := range map[string]int{"1": 2, "2": 2, "3": 1} {
+		if calls := p.Calls(id); calls != want {
+			t.Errorf("%s fetched %d times, want %d", id, calls, want)
+		}
+	}
+
+	for id, want := range map[string]string{"1": "renamed", "3": "missing"} {
+		cached, err := state.PlatformUserCache.Get(ctx, "mock:"+id)
+
+		if err != nil {
+			t.Fatalf("%s not cached: %s", id, err)
+		}
+
+		if cached.Username != want {
+			t.Errorf("%s cached as %s, want %s", id, cached.Username, want)
+		}
+	}
+}
+
+func TestRefreshLogLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  zapcore.Level
+		logged bool
+	}{
+		{"default", 0, true},
+		{"debug", zapcore.DebugLevel, false},
+		{"warn", zapcore.WarnLevel, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.InfoLevel)
+
+			state := platformtest.NewState()
+			state.Logger = zap.New(core)
+			state.RefreshLogLevel = tt.level
+
+			p := platformtest.New(state, &dovetypes.PlatformUser{ID: "1", Username: "user"})
+			p.Init()
+
+			dovewing.RefreshUser(p, "1")
+
+			refreshes := logs.FilterMessage("Updating expired user cache").All()
+
+			if !tt.logged {
+				if len(refreshes) != 0 {
+					t.Fatalf("refresh logged below the core level: %+v", refreshes)
+				}
+
+				return
+			}
+
+			if len(refreshes) != 1 || refreshes[0].Level != tt.level {
+				t.Fatalf("got %+v, want one refresh log at %s", refreshes, tt.level)
+			}
+		})
+	}
+}
+
+func TestRefreshErrorsAlwaysLogged(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	state := platformtest.NewState()
+	state.Logger = zap.New(core)
+	state.RefreshLogLevel = zapcore.DebugLevel
+
+	p := platformtest.New(state)
+	p.Init()
+
+	dovewing.RefreshUser(p, "1")
+
+	if errs := logs.FilterLevelExact(zapcore.ErrorLevel).Len(); errs != 1 {
+		t.Fatalf("got %d error logs, want 1", errs)
+	}
+}
+
+func TestMiddlewareErrorPolicy(t *testing.T) {
+	middlewares := []func(p dovewing.Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error){
+		func(p dovewing.Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+			u.DisplayName = "enriched"
+			return u, nil
+		},
+		func(p dovewing.Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+			return nil, errors.New("enricher unavailable")
+		},
+		func(p dovewing.Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+			u.Bot = true
+			return u, nil
+		},
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		state := platformtest.NewState()
+		state.Middlewares = middlewares
+		state.MiddlewareErrorPolicy = dovewing.MiddlewareErrorSkip
+
+		u, err := dovewing.GetUser(context.Background(), "1", platformtest.New(state, &dovetypes.PlatformUser{ID: "1", Username: "user"}))
+
+		if err != nil {
+			t.Fatalf("skipping middleware aborted the fetch: %s", err)
+		}
+
+		if u.DisplayName != "enriched" || !u.Bot {
+			t.Fatalf("middlewares around the failing one were not applied: %+v", u)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		state := platformtest.NewState()
+		state.Middlewares = middlewares
+
+		_, err := dovewing.GetUser(context.Background(), "1", platformtest.New(state, &dovetypes.PlatformUser{ID: "1", Username: "user"}))
+
+		if err == nil || !strings.Contains(err.Error(), "enricher unavailable") {
+			t.Fatalf("got %v, want the middleware error", err)
+		}
+
+		if exists, _ := state.PlatformUserCache.Exists(context.Background(), "mock:1"); exists {
+			t.Fatal("user was cached despite the failing middleware")
+		}
+	})
+}
+
+// A mock platform whose generated default avatars live under /embed/
+type defaultAvatarPlatform struct {
+	*platformtest.MockPlatform
+}
+
+func (defaultAvatarPlatform) IsDefaultAvatar(u *dovetypes.PlatformUser) bool {
+	return strings.HasPrefix(u.Avatar, "https://cdn.example/embed/")
+}
+
+func TestDefaultAvatar(t *testing.T) {
+	state := platformtest.NewState()
+	state.DefaultAvatar = func(p dovewing.Platform, u *dovetypes.PlatformUser) string {
+		return "https://brand.example/placeholder.png"
+	}
+
+	p := defaultAvatarPlatform{platformtest.New(
+		state,
+		&dovetypes.PlatformUser{ID: "1", Username: "none"},
+		&dovetypes.PlatformUser{ID: "2", Username: "custom", Avatar: "https://cdn.example/avatars/2.png"},
+		&dovetypes.PlatformUser{ID: "3", Username: "generated", Avatar: "https://cdn.example/embed/3.png"},
+	)}
+
+	want := map[string]string{
+		"1": "https://brand.example/placeholder.png",
+		"2": "https://cdn.example/avatars/2.png",
+		"3": "https://brand.example/placeholder.png",
+	}
+
+	for id, avatar := range want {
+		u, err := dovewing.GetUser(context.Background(), id, p)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.Avatar != avatar {
+			t.Errorf("%s: got avatar %q, want %q", id, u.Avatar, avatar)
+		}
+	}
+}
+
+func TestMiddlewareTrace(t *testing.T) {
+	middlewares := []func(p dovewing.Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error){
+		dovewing.NamedMiddleware("nickname", func(p dovewing.Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+			u.DisplayName = "nick"
+			return u, nil
+		}),
+		// Runs but changes nothing, so is not traced
+		dovewing.NamedMiddleware("noop", func(p dovewing.Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+			return u, nil
+		}),
+		// Not named, so never traced
+		func(p dovewing.Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+			u.Avatar = "https://cdn.example/avatar.png"
+			return u, nil
+		},
+		dovewing.NamedMiddleware("badges", func(p dovewing.Platform, u *dovetypes.PlatformUser) (*dovetypes.PlatformUser, error) {
+			u.Flags = append(u.Flags, "verified")
+			return u, nil
+		}),
+	}
+
+	for _, trace := range []bool{true, false} {
+		state := platformtest.NewState()
+		state.Middlewares = middlewares
+		state.TraceMiddlewares = trace
+
+		p := platformtest.New(state, &dovetypes.PlatformUser{ID: "1", Username: "user"})
+
+		// The second fetch is a cache hit, which must keep the trace
+		for _, cache := range []string{"", "redis"} {
+			u, err := dovewing.GetUser(context.Background(), "1", p)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, _ := u.ExtraData["cache"].(string); got != cache {
+				t.Fatalf("got cache %q, want %q", got, cache)
+			}
+
+			got, ok := u.ExtraData[dovewing.MiddlewareTraceKey].([]string)
+
+			if !trace {
+				if ok {
+					t.Fatalf("trace recorded without TraceMiddlewares: %v", got)
+				}
+
+				continue
+			}
+
+			if want := []string{"nickname", "badges"}; strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Fatalf("got trace %v on fetch from %q, want %v", got, cache, want)
+			}
+		}
+	}
+}
+
+// Counts the SETs sent to redis, and how many of them were pipelined
+type setCounter struct {
+	mu        sync.Mutex
+	pipelines int
+	pipelined int
+	single    int
+}
+
+func (h *setCounter) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *setCounter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if cmd.Name() == "set" {
+			h.mu.Lock()
+			h.single++
+			h.mu.Unlock()
+		}
+
+		return next(ctx, cmd)
+	}
+}
+
+func (h *setCounter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.mu.Lock()
+		h.pipelines++
+
+		for _, cmd := range cmds {
+			if cmd.Name() == "set" {
+				h.pipelined++
+			}
+		}
+
+		h.mu.Unlock()
+
+		return next(ctx, cmds)
+	}
+}
+
+func batchUsers(n int) (users []*dovetypes.PlatformUser, ids []string) {
+	for i := 0; i < n; i++ {
+		id := strconv.Itoa(1000 + i)
+		users = append(users, &dovetypes.PlatformUser{ID: id, Username: "user" + id})
+		ids = append(ids, id)
+	}
+
+	return users, ids
+}
+
+func TestWarmCacheRedisPipeline(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	t.Cleanup(func() { rdb.Close() })
+
+	hook := &setCounter{}
+	rdb.AddHook(hook)
+
+	state := platformtest.NewState()
+	state.PlatformUserCache = redishotcache.RedisHotCache[dovetypes.PlatformUser]{Redis: rdb}
+
+	users, ids := batchUsers(20)
+	p := platformtest.New(state, users...)
+
+	warmed, err := dovewing.WarmCache(context.Background(), ids, p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if warmed != 20 {
+		t.Fatalf("warmed %d users, want 20", warmed)
+	}
+
+	if hook.pipelines != 1 || hook.pipelined != 20 || hook.single != 0 {
+		t.Fatalf("got %d pipelines with %d sets and %d unpipelined sets, want 1 pipeline with 20 sets", hook.pipelines, hook.pipelined, hook.single)
+	}
+
+	for _, id := range ids {
+		if !mr.Exists("mock:" + id) {
+			t.Fatalf("%s not cached in redis", id)
+		}
+	}
+}
+
+// Counts the INSERT statements run against postgres
+type insertCounter struct {
+	mu      sync.Mutex
+	inserts []string
+}
+
+func (c *insertCounter) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if strings.HasPrefix(data.SQL, "INSERT") {
+		c.mu.Lock()
+		c.inserts = append(c.inserts, data.SQL)
+		c.mu.Unlock()
+	}
+
+	return ctx
+}
+
+func (c *insertCounter) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+}
+
+func TestWarmCacheSingleUpsert(t *testing.T) {
+	// The upsert is done in SQL, so this needs a real Postgres
+	dsn := os.Getenv("DOVEWING_TEST_POSTGRES")
+
+	if dsn == "" {
+		t.Skip("DOVEWING_TEST_POSTGRES is not set")
+	}
+
+	ctx := context.Background()
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := &insertCounter{}
+	cfg.ConnConfig.Tracer = tracer
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer pool.Close()
+
+	state := platformtest.NewState()
+	state.Pool = pool
+
+	users, ids := batchUsers(20)
+
+	p := platformtest.New(state, users...)
+	p.Name = "warmtest"
+
+	if err := dovewing.InitPlatform(p); err != nil {
+		t.Fatal(err)
+	}
+
+	table := dovewing.TableName(p)
+
+	defer pool.Exec(ctx, "DROP TABLE "+table)
+
+	if _, err := pool.Exec(ctx, "DELETE FROM "+table); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dovewing.WarmCache(ctx, ids, p); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracer.inserts) != 1 || !strings.Contains(tracer.inserts[0], "unnest") {
+		t.Fatalf("got %d inserts, want a single unnest upsert: %v", len(tracer.inserts), tracer.inserts)
+	}
+
+	var count int
+
+	if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 20 {
+		t.Fatalf("got %d rows, want 20", count)
+	}
+}
@@ -3,7 +3,12 @@ package dovewing
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
@@ -28,7 +33,17 @@ func flagsToArray(u *discordgo.User) []string {
 	return arr
 }
 
-func discordPlatformStatus(status discordgo.Status) dovetypes.PlatformStatus {
+// Returns the accent color as a hex string, or an empty string if the user has no accent color
+func discordAccentColor(color int) string {
+	if color == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("#%06x", color)
+}
+
+// Maps a discord status to a platform status, unknown and invisible statuses map to offline
+func DiscordPlatformStatus(status discordgo.Status) dovetypes.PlatformStatus {
 	switch status {
 	case discordgo.StatusOnline:
 		return dovetypes.PlatformStatusOnline
@@ -41,15 +56,87 @@ func discordPlatformStatus(status discordgo.Status) dovetypes.PlatformStatus {
 	}
 }
 
+// Maps a platform status back to a discord status, unknown statuses map to offline
+func PlatformStatusToDiscord(status dovetypes.PlatformStatus) discordgo.Status {
+	switch status {
+	case dovetypes.PlatformStatusOnline:
+		return discordgo.StatusOnline
+	case dovetypes.PlatformStatusIdle:
+		return discordgo.StatusIdle
+	case dovetypes.PlatformStatusDoNotDisturb:
+		return discordgo.StatusDoNotDisturb
+	default:
+		return discordgo.StatusOffline
+	}
+}
+
 type DiscordState struct {
 	config      *DiscordStateConfig // Config for the discord state
 	initialized bool                // Whether the platform has been initted or not
+	restSem     chan struct{}       // Bounds concurrent REST fetches, nil if unbounded
+
+	// Looks up a member in the session state, overridden in tests
+	memberLookup func(guildID, userID string) (*discordgo.Member, error)
 }
 
 type DiscordStateConfig struct {
 	Session        *discordgo.Session // Discord session
 	PreferredGuild string             // Which guilds should be checked first for users, good if theres one guild with the majority of users
 	BaseState      *BaseState         // Base state
+
+	// The number of guilds searched in parallel when a user is not in the preferred guild, defaults to 8
+	GuildScanWorkers int
+
+	// Size of resolved avatar URLs, must be a power of two between 16 and 4096. Defaults to Discord's default size
+	AvatarSize int
+
+	// Format of static avatars (png, jpg or webp), defaults to png. Animated avatars are always gifs
+	AvatarFormat string
+
+	// The maximum number of REST fetches made to discord at once, defaults to 4. Negative means unbounded
+	MaxConcurrentRequests int
+
+	// How many times a REST fetch is retried after being ratelimited (429), waiting for the Retry-After
+	// discord returns in between. Defaults to 3, negative disables retries
+	RatelimitRetries int
+
+	// Bounds on the number of digits in a valid snowflake, default to MinSnowflakeLength and MaxSnowflakeLength
+	MinIdLength int
+	MaxIdLength int
+}
+
+const (
+	// The shortest snowflakes (from early 2015) have 17 digits
+	MinSnowflakeLength = 17
+	// A snowflake is a uint64, which has at most 20 digits
+	MaxSnowflakeLength = 20
+)
+
+// Users without an avatar get one of discords generated embed avatars
+func (d *DiscordState) IsDefaultAvatar(u *dovetypes.PlatformUser) bool {
+	return strings.Contains(u.Avatar, "/embed/avatars/")
+}
+
+// Returns the avatar URL of a user based on the configured size and format
+func (c *DiscordStateConfig) avatarURL(u *discordgo.User) string {
+	var size string
+
+	if c.AvatarSize > 0 {
+		size = strconv.Itoa(c.AvatarSize)
+	}
+
+	// Default and animated (a_ prefixed) avatars are handled by discordgo
+	if c.AvatarFormat == "" || u.Avatar == "" || strings.HasPrefix(u.Avatar, "a_") {
+		return u.AvatarURL(size)
+	}
+
+	url := discordgo.EndpointCDNAvatars + u.ID + "/" + u.Avatar + "." + c.AvatarFormat
+
+	if size != "" {
+		url += "?size=" + size
+	}
+
+	return url
 }
 
 func (c DiscordStateConfig) New() (*DiscordState, error) {
@@ -61,8 +148,32 @@ func (c DiscordStateConfig) New() (*DiscordState, error) {
 		return nil, errors.New("base state not provided")
 	}
 
+	// Discord rejects any other size, leaving users with broken avatars
+	if c.AvatarSize != 0 && (c.AvatarSize < 16 || c.AvatarSize > 4096 || c.AvatarSize&(c.AvatarSize-1) != 0) {
+		return nil, fmt.Errorf("avatar size must be a power of two between 16 and 4096, got %d", c.AvatarSize)
+	}
+
+	switch c.AvatarFormat {
+	case "", "png", "jpg", "webp":
+	default:
+		return nil, fmt.Errorf("unsupported avatar format: %s", c.AvatarFormat)
+	}
+
+	maxConcurrent := c.MaxConcurrentRequests
+
+	if maxConcurrent == 0 {
+		maxConcurrent = defaultMaxConcurrentRequests
+	}
+
+	var restSem chan struct{}
+
+	if maxConcurrent > 0 {
+		restSem = make(chan struct{}, maxConcurrent)
+	}
+
 	return &DiscordState{
-		config: &c,
+		config:  &c,
+		restSem: restSem,
 	}, nil
 }
 
@@ -89,100 +200,290 @@ func (d *DiscordState) ValidateId(id string) (string, error) {
 		return "", err
 	}
 
+	minLength, maxLength := d.config.MinIdLength, d.config.MaxIdLength
+
+	if minLength <= 0 {
+		minLength = MinSnowflakeLength
+	}
+
+	if maxLength <= 0 {
+		maxLength = MaxSnowflakeLength
+	}
+
 	// For all practical purposes, a simple length check can handle a lot of illegal IDs
-	if len(id) <= 16 || len(id) > 20 {
+	if len(id) < minLength || len(id) > maxLength {
 		return "", errors.New("invalid snowflake")
 	}
 
 	return id, nil
 }
 
-func (d *DiscordState) PlatformSpecificCache(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
-	// First try for main server
-	if d.config.PreferredGuild != "" {
-		member, err := d.config.Session.State.Member(d.config.PreferredGuild, id)
+// Reports an error if the gateway connection is down, in which case the state cache is stale
+func (d *DiscordState) HealthCheck(ctx context.Context) error {
+	d.config.Session.RLock()
+	defer d.config.Session.RUnlock()
 
-		if err == nil {
-			p, pErr := d.config.Session.State.Presence(d.config.PreferredGuild, id)
+	if !d.config.Session.DataReady {
+		return errors.New("discord gateway is not connected")
+	}
+
+	return nil
+}
+
+const defaultGuildScanWorkers = 8
+
+// Returns the member of a guild from the session state
+func (d *DiscordState) member(guildID, userID string) (*discordgo.Member, error) {
+	if d.memberLookup != nil {
+		return d.memberLookup(guildID, userID)
+	}
+
+	return d.config.Session.State.Member(guildID, userID)
+}
+
+// Builds a user from a guild member and the users presence in that guild
+func (d *DiscordState) memberToUser(guildID string, member *discordgo.Member) *dovetypes.PlatformUser {
+	p, pErr := d.config.Session.State.Presence(guildID, member.User.ID)
+
+	if pErr != nil {
+		p = &discordgo.Presence{
+			User:   member.User,
+			Status: discordgo.StatusOffline,
+		}
+	}
+
+	return &dovetypes.PlatformUser{
+		ID:          member.User.ID,
+		Username:    member.User.Username,
+		Avatar:      d.config.avatarURL(member.User),
+		DisplayName: member.User.GlobalName,
+		Bot:         member.User.Bot,
+		Flags:       flagsToArray(member.User),
+		Banner:      member.User.BannerURL(""),
+		AccentColor: discordAccentColor(member.User.AccentColor),
+		ExtraData: map[string]any{
+			"nickname":        member.Nick,
+			"mutual_guild":    guildID,
+			"preferred_guild": guildID == d.config.PreferredGuild,
+			"public_flags":    member.User.PublicFlags,
+		},
+		Status: DiscordPlatformStatus(p.Status),
+	}
+}
+
+type guildMember struct {
+	guildID string
+	member  *discordgo.Member
+}
+
+// Searches the guilds for the member using a bounded pool of workers, returning the first match found
+func (d *DiscordState) scanGuilds(ctx context.Context, id string, guildIDs []string) *guildMember {
+	if len(guildIDs) == 0 {
+		return nil
+	}
 
-			if pErr != nil {
-				p = &discordgo.Presence{
-					User:   member.User,
-					Status: discordgo.StatusOffline,
+	workers := d.config.GuildScanWorkers
+
+	if workers <= 0 {
+		workers = defaultGuildScanWorkers
+	}
+
+	if workers > len(guildIDs) {
+		workers = len(guildIDs)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan string)
+	found := make(chan guildMember, 1)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for guildID := range jobs {
+				member, err := d.member(guildID, id)
+
+				if err != nil {
+					continue
 				}
+
+				select {
+				case found <- guildMember{guildID: guildID, member: member}:
+					// Stop feeding the other workers
+					cancel()
+				default:
+					// Another worker already found the member
+				}
+
+				return
 			}
+		}()
+	}
 
-			return &dovetypes.PlatformUser{
-				ID:          id,
-				Username:    member.User.Username,
-				Avatar:      member.User.AvatarURL(""),
-				DisplayName: member.User.GlobalName,
-				Bot:         member.User.Bot,
-				Flags:       flagsToArray(member.User),
-				ExtraData: map[string]any{
-					"nickname":        member.Nick,
-					"mutual_guild":    d.config.PreferredGuild,
-					"preferred_guild": true,
-					"public_flags":    member.User.PublicFlags,
-				},
-				Status: discordPlatformStatus(p.Status),
-			}, nil
+feed:
+	for _, guildID := range guildIDs {
+		select {
+		case jobs <- guildID:
+		case <-ctx.Done():
+			break feed
 		}
 	}
 
+	close(jobs)
+	wg.Wait()
+
+	select {
+	case m := <-found:
+		return &m
+	default:
+		return nil
+	}
+}
+
+func (d *DiscordState) PlatformSpecificCache(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
+	// First try for main server
+	if d.config.PreferredGuild != "" {
+		member, err := d.member(d.config.PreferredGuild, id)
+
+		if err == nil {
+			return d.memberToUser(d.config.PreferredGuild, member), nil
+		}
+	}
+
+	// Snapshot the guild IDs as the state may change while scanning
+	if m := d.scanGuilds(ctx, id, d.otherGuildIDs()); m != nil {
+		return d.memberToUser(m.guildID, m.member), nil
+	}
+
+	return nil, nil
+}
+
+// Snapshots the IDs of every guild in the state except the preferred guild
+//
+// Gateway events change the guilds under the state lock, so they must not be ranged over directly
+func (d *DiscordState) otherGuildIDs() []string {
+	d.config.Session.State.RLock()
+	defer d.config.Session.State.RUnlock()
+
+	guildIDs := make([]string, 0, len(d.config.Session.State.Guilds))
+
 	for _, guild := range d.config.Session.State.Guilds {
 		if guild.ID == d.config.PreferredGuild {
 			continue // Already checked
 		}
 
-		member, err := d.config.Session.State.Member(guild.ID, id)
+		guildIDs = append(guildIDs, guild.ID)
+	}
+
+	return guildIDs
+}
+
+// Returns the status of a user from the presences in the state, offline if no presence is found
+func (d *DiscordState) GetStatus(ctx context.Context, id string) (dovetypes.PlatformStatus, error) {
+	if d.config.PreferredGuild != "" {
+		p, err := d.config.Session.State.Presence(d.config.PreferredGuild, id)
 
 		if err == nil {
-			p, pErr := d.config.Session.State.Presence(guild.ID, id)
+			return DiscordPlatformStatus(p.Status), nil
+		}
+	}
 
-			if pErr != nil {
-				p = &discordgo.Presence{
-					User:   member.User,
-					Status: discordgo.StatusOffline,
-				}
-			}
+	for _, guildID := range d.otherGuildIDs() {
+		p, err := d.config.Session.State.Presence(guildID, id)
 
-			return &dovetypes.PlatformUser{
-				ID:          id,
-				Username:    member.User.Username,
-				Avatar:      member.User.AvatarURL(""),
-				DisplayName: member.User.GlobalName,
-				Bot:         member.User.Bot,
-				Flags:       flagsToArray(member.User),
-				ExtraData: map[string]any{
-					"nickname":        member.Nick,
-					"mutual_guild":    guild.ID,
-					"preferred_guild": false,
-					"public_flags":    member.User.PublicFlags,
-				},
-				Status: discordPlatformStatus(p.Status),
-			}, nil
+		if err == nil {
+			return DiscordPlatformStatus(p.Status), nil
 		}
 	}
 
-	return nil, nil
+	return dovetypes.PlatformStatusOffline, nil
+}
+
+// Maps discord errors to ErrUserNotFound and ErrPlatformUnavailable
+func discordError(err error) error {
+	var restErr *discordgo.RESTError
+
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		switch {
+		case restErr.Response.StatusCode == http.StatusNotFound:
+			return ErrUserNotFound
+		case restErr.Response.StatusCode >= 500:
+			return fmt.Errorf("%w: %s", ErrPlatformUnavailable, err)
+		}
+
+		return err
+	}
+
+	// Anything else is a ratelimit or network level failure
+	return fmt.Errorf("%w: %s", ErrPlatformUnavailable, err)
+}
+
+const (
+	defaultMaxConcurrentRequests = 4
+	defaultRatelimitRetries      = 3
+)
+
+// Fetches a user over REST, bounding concurrent fetches and retrying when ratelimited
+func (d *DiscordState) fetchUser(ctx context.Context, id string) (*discordgo.User, error) {
+	if d.restSem != nil {
+		select {
+		case d.restSem <- struct{}{}:
+			defer func() { <-d.restSem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	retries := d.config.RatelimitRetries
+
+	if retries == 0 {
+		retries = defaultRatelimitRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		// Ratelimits are retried here so the wait can be cancelled through ctx
+		user, err := d.config.Session.User(id, discordgo.WithContext(ctx), discordgo.WithRetryOnRatelimit(false))
+
+		var rlErr *discordgo.RateLimitError
+
+		if !errors.As(err, &rlErr) || attempt >= retries {
+			return user, err
+		}
+
+		timer := time.NewTimer(rlErr.RetryAfter)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
 }
 
 func (d *DiscordState) GetUser(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
 	// Get from discord
-	user, err := d.config.Session.User(id)
+	user, err := d.fetchUser(ctx, id)
 
 	if err != nil {
-		return nil, err
+		return nil, discordError(err)
 	}
 
 	return &dovetypes.PlatformUser{
 		ID:          id,
 		Username:    user.Username,
-		Avatar:      user.AvatarURL(""),
+		Avatar:      d.config.avatarURL(user),
 		DisplayName: user.GlobalName,
 		Bot:         user.Bot,
 		Status:      dovetypes.PlatformStatusOffline,
 		Flags:       flagsToArray(user),
+		Banner:      user.BannerURL(""),
+		AccentColor: discordAccentColor(user.AccentColor),
 	}, nil
 }
@@ -0,0 +1,201 @@
+package dovewing_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+func TestDiscordAvatarOptions(t *testing.T) {
+	tests := []struct {
+		size   int
+		format string
+		valid  bool
+	}{
+		{0, "", true},
+		{16, "png", true},
+		{128, "webp", true},
+		{4096, "jpg", true},
+		{8, "", false},
+		{100, "", false},
+		{8192, "", false},
+		{-64, "", false},
+		{0, "gif", false},
+		{0, "bmp", false},
+	}
+
+	for _, tt := range tests {
+		_, err := dovewing.DiscordStateConfig{
+			Session:      &discordgo.Session{},
+			BaseState:    platformtest.NewState(),
+			AvatarSize:   tt.size,
+			AvatarFormat: tt.format,
+		}.New()
+
+		if (err == nil) != tt.valid {
+			t.Errorf("size %d format %q: got error %v, want valid=%v", tt.size, tt.format, err, tt.valid)
+		}
+	}
+}
+
+func TestDiscordHealthCheck(t *testing.T) {
+	for _, ready := range []bool{true, false} {
+		d, err := dovewing.DiscordStateConfig{
+			Session:   &discordgo.Session{DataReady: ready},
+			BaseState: platformtest.NewState(),
+		}.New()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = dovewing.PlatformHealth(context.Background(), d)
+
+		if ready && err != nil {
+			t.Fatalf("connected session reported unhealthy: %s", err)
+		}
+
+		if !ready && err == nil {
+			t.Fatal("disconnected session reported healthy")
+		}
+	}
+}
+
+func TestNoHealthCheck(t *testing.T) {
+	if err := dovewing.PlatformHealth(context.Background(), platformtest.New(nil)); err != nil {
+		t.Fatalf("platform without a health check reported unhealthy: %s", err)
+	}
+}
+
+func TestDiscordValidateId(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"8035111022467891", false},      // 16 digits
+		{"80351110224678912", true},      // 17 digits, an account from 2015
+		{"41771983423143937", true},      // 17 digits, discord's first guild
+		{"302050872383242240", true},     // 18 digits
+		{"1100000000000000000", true},    // 19 digits, newer accounts
+		{"18446744073709551615", true},   // 20 digits, the largest uint64
+		{"184467440737095516150", false}, // 21 digits
+		{"80351110224678912a", false},
+		{"", false},
+	}
+
+	d, err := dovewing.DiscordStateConfig{Session: &discordgo.Session{}, BaseState: platformtest.NewState()}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		if _, err := d.ValidateId(tt.id); (err == nil) != tt.valid {
+			t.Errorf("%q (%d digits): got error %v, want valid=%v", tt.id, len(tt.id), err, tt.valid)
+		}
+	}
+}
+
+func TestDiscordValidateIdBounds(t *testing.T) {
+	d, err := dovewing.DiscordStateConfig{
+		Session:     &discordgo.Session{},
+		BaseState:   platformtest.NewState(),
+		MinIdLength: 18,
+		MaxIdLength: 19,
+	}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, valid := range map[string]bool{
+		"80351110224678912":    false,
+		"302050872383242240":   true,
+		"1100000000000000000":  true,
+		"18446744073709551615": false,
+	} {
+		if _, err := d.ValidateId(id); (err == nil) != valid {
+			t.Errorf("%q: got error %v, want valid=%v", id, err, valid)
+		}
+	}
+}
+
+func TestDiscordStatusMapping(t *testing.T) {
+	for _, status := range []dovetypes.PlatformStatus{
+		dovetypes.PlatformStatusOnline,
+		dovetypes.PlatformStatusIdle,
+		dovetypes.PlatformStatusDoNotDisturb,
+		dovetypes.PlatformStatusOffline,
+	} {
+		if got := dovewing.DiscordPlatformStatus(dovewing.PlatformStatusToDiscord(status)); got != status {
+			t.Errorf("%s round-tripped to %s", status, got)
+		}
+	}
+
+	// Unknown statuses default to offline
+	for _, status := range []discordgo.Status{discordgo.StatusInvisible, "", "streaming"} {
+		if got := dovewing.DiscordPlatformStatus(status); got != dovetypes.PlatformStatusOffline {
+			t.Errorf("discord status %q mapped to %s, want offline", status, got)
+		}
+	}
+
+	if got := dovewing.PlatformStatusToDiscord("unknown"); got != discordgo.StatusOffline {
+		t.Errorf("unknown platform status mapped to %s, want offline", got)
+	}
+}
+
+func TestDiscordGetStatusConcurrentGuilds(t *testing.T) {
+	session := &discordgo.Session{State: discordgo.NewState()}
+
+	for i := 0; i < 10; i++ {
+		if err := session.State.GuildAdd(&discordgo.Guild{ID: "g" + strconv.Itoa(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := session.State.PresenceAdd("g5", &discordgo.Presence{User: &discordgo.User{ID: "42"}, Status: discordgo.StatusIdle})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := dovewing.DiscordStateConfig{Session: session, BaseState: platformtest.NewState()}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Guilds are joined while statuses are looked up, run with -race to catch unlocked reads
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 10; i < 1000; i++ {
+			session.State.GuildAdd(&discordgo.Guild{ID: "g" + strconv.Itoa(i)})
+		}
+	}()
+
+	for {
+		status, err := d.GetStatus(context.Background(), "42")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status != dovetypes.PlatformStatusIdle {
+			t.Fatalf("got status %s, want idle", status)
+		}
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+	}
+}
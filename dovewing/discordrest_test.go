@@ -0,0 +1,121 @@
+package dovewing_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+// Answers discord REST requests with 429s for the first ratelimited calls, then the user
+type discordTransport struct {
+	mu          sync.Mutex
+	ratelimited int
+	calls       []time.Time
+}
+
+func (rt *discordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.calls = append(rt.calls, time.Now())
+
+	status, body := http.StatusOK, `{"id": "123456789012345678", "username": "octocat"}`
+
+	if len(rt.calls) <= rt.ratelimited {
+		status, body = http.StatusTooManyRequests, `{"message": "You are being rate limited.", "retry_after": 0.1, "global": false}`
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func newRESTDiscord(t *testing.T, rt *discordTransport, retries int) *dovewing.DiscordState {
+	t.Helper()
+
+	session, err := discordgo.New("Bot test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session.Client = &http.Client{Transport: rt}
+
+	d, err := dovewing.DiscordStateConfig{
+		Session:          session,
+		BaseState:        platformtest.NewState(),
+		RatelimitRetries: retries,
+	}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+func TestDiscordRatelimitRetry(t *testing.T) {
+	rt := &discordTransport{ratelimited: 1}
+	d := newRESTDiscord(t, rt, 0)
+
+	u, err := d.GetUser(context.Background(), "123456789012345678")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Username != "octocat" {
+		t.Fatalf("got %+v", u)
+	}
+
+	if len(rt.calls) != 2 {
+		t.Fatalf("got %d requests, want 2", len(rt.calls))
+	}
+
+	if wait := rt.calls[1].Sub(rt.calls[0]); wait < 100*time.Millisecond {
+		t.Fatalf("retried after %s, before the 100ms Retry-After", wait)
+	}
+}
+
+func TestDiscordRatelimitRetriesExhausted(t *testing.T) {
+	rt := &discordTransport{ratelimited: 10}
+	d := newRESTDiscord(t, rt, 2)
+
+	_, err := d.GetUser(context.Background(), "123456789012345678")
+
+	if !errors.Is(err, dovewing.ErrPlatformUnavailable) {
+		t.Fatalf("got %v, want ErrPlatformUnavailable", err)
+	}
+
+	if len(rt.calls) != 3 {
+		t.Fatalf("got %d requests, want the first attempt and 2 retries", len(rt.calls))
+	}
+}
+
+func TestDiscordRatelimitWaitCancelled(t *testing.T) {
+	rt := &discordTransport{ratelimited: 10}
+	d := newRESTDiscord(t, rt, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := d.GetUser(ctx, "123456789012345678"); !errors.Is(err, dovewing.ErrPlatformUnavailable) || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Fatalf("got %v, want the context error", err)
+	}
+
+	if len(rt.calls) != 1 {
+		t.Fatalf("got %d requests, want no retry after cancellation", len(rt.calls))
+	}
+}
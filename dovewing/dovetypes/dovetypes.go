@@ -9,6 +9,20 @@ const (
 	PlatformStatusOffline      PlatformStatus = "offline"
 )
 
+// Reports whether the status is one of the known platform statuses
+func (s PlatformStatus) Valid() bool {
+	switch s {
+	case PlatformStatusOnline, PlatformStatusIdle, PlatformStatusDoNotDisturb, PlatformStatusOffline:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s PlatformStatus) String() string {
+	return string(s)
+}
+
 type PlatformUser struct {
 	ID          string         `json:"id" description:"The users ID"`
 	Username    string         `json:"username" description:"The users username"`
@@ -17,5 +31,7 @@ type PlatformUser struct {
 	Bot         bool           `json:"bot" description:"Whether the user is a bot or not"`
 	Status      PlatformStatus `json:"status" description:"The users current status"`
 	Flags       []string       `json:"flags" description:"The users flags. Note that dovewing has its own list of flags"`
+	Banner      string         `json:"banner" description:"The users resolved banner URL, empty if the user has no banner or the platform does not support banners"`
+	AccentColor string         `json:"accent_color" description:"The users accent color as a hex string (e.g. #5865f2), empty if not set or not supported by the platform"`
 	ExtraData   map[string]any `json:"extra_data" description:"Platform specific extra data"`
 }
@@ -0,0 +1,28 @@
+package dovetypes
+
+import "testing"
+
+func TestPlatformStatus(t *testing.T) {
+	tests := []struct {
+		status PlatformStatus
+		valid  bool
+	}{
+		{PlatformStatusOnline, true},
+		{PlatformStatusIdle, true},
+		{PlatformStatusDoNotDisturb, true},
+		{PlatformStatusOffline, true},
+		{"invisible", false},
+		{"", false},
+		{"Online", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.Valid(); got != tt.valid {
+			t.Errorf("%q: got Valid() %v, want %v", tt.status, got, tt.valid)
+		}
+
+		if got := tt.status.String(); got != string(tt.status) {
+			t.Errorf("got String() %q, want %q", got, string(tt.status))
+		}
+	}
+}
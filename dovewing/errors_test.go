@@ -0,0 +1,108 @@
+package dovewing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+func TestGitHubErrorSentinels(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		want    error
+	}{
+		{"not found", http.StatusNotFound, nil, dovewing.ErrUserNotFound},
+		{"server error", http.StatusBadGateway, nil, dovewing.ErrPlatformUnavailable},
+		{"secondary ratelimit", http.StatusForbidden, map[string]string{"Retry-After": "60"}, dovewing.ErrPlatformUnavailable},
+		{"primary ratelimit", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1700000000"}, dovewing.ErrPlatformUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.headers {
+					w.Header().Set(k, v)
+				}
+
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			gh, err := dovewing.GitHubStateConfig{BaseURL: srv.URL, BaseState: platformtest.NewState()}.New()
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = dovewing.GetUser(context.Background(), "octocat", gh)
+
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitHubUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	gh, err := dovewing.GitHubStateConfig{BaseURL: srv.URL, BaseState: platformtest.NewState()}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = dovewing.GetUser(context.Background(), "octocat", gh)
+
+	if !errors.Is(err, dovewing.ErrPlatformUnavailable) {
+		t.Fatalf("got %v, want ErrPlatformUnavailable", err)
+	}
+}
+
+func TestDiscordErrorSentinels(t *testing.T) {
+	restErr := func(status int) error {
+		return &discordgo.RESTError{Response: &http.Response{StatusCode: status}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"not found", restErr(http.StatusNotFound), dovewing.ErrUserNotFound},
+		{"server error", restErr(http.StatusInternalServerError), dovewing.ErrPlatformUnavailable},
+		{"network error", errors.New("connection reset by peer"), dovewing.ErrPlatformUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := dovewing.DiscordError(tt.err); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// Other client errors are passed through, they are neither not found nor an outage
+	err := dovewing.DiscordError(restErr(http.StatusForbidden))
+
+	if errors.Is(err, dovewing.ErrUserNotFound) || errors.Is(err, dovewing.ErrPlatformUnavailable) {
+		t.Fatalf("403 mapped to %v", err)
+	}
+}
+
+func TestMockPlatformUserNotFound(t *testing.T) {
+	p := platformtest.New(nil)
+
+	if _, err := dovewing.GetUser(context.Background(), "404", p); !errors.Is(err, dovewing.ErrUserNotFound) {
+		t.Fatalf("got %v, want ErrUserNotFound", err)
+	}
+}
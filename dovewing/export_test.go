@@ -0,0 +1,11 @@
+package dovewing
+
+import "github.com/bwmarrin/discordgo"
+
+// Exposes internals to the dovewing_test package
+var RefreshUser = refreshUser
+var DiscordError = discordError
+
+func SetMemberLookup(d *DiscordState, lookup func(guildID, userID string) (*discordgo.Member, error)) {
+	d.memberLookup = lookup
+}
@@ -0,0 +1,194 @@
+package dovewing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+)
+
+const defaultGitHubBaseURL = "https://api.github.com"
+
+// GitHub logins are alphanumeric with single hyphens, and cannot start or end with a hyphen
+var githubLoginRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,37}[A-Za-z0-9])?$`)
+
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+	Type      string `json:"type"`
+	HTMLURL   string `json:"html_url"`
+}
+
+type GitHubState struct {
+	NoHealthCheck
+	config      *GitHubStateConfig // Config for the github state
+	initialized bool               // Whether the platform has been initted or not
+}
+
+type GitHubStateConfig struct {
+	Token     string       // Token to authenticate with, optional but unauthenticated requests have a very low ratelimit
+	BaseURL   string       // Base URL of the API, defaults to https://api.github.com
+	Client    *http.Client // HTTP client to use, defaults to http.DefaultClient
+	BaseState *BaseState   // Base state
+}
+
+func (c GitHubStateConfig) New() (*GitHubState, error) {
+	if c.BaseState == nil {
+		return nil, errors.New("base state not provided")
+	}
+
+	if c.BaseURL == "" {
+		c.BaseURL = defaultGitHubBaseURL
+	}
+
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+
+	return &GitHubState{
+		config: &c,
+	}, nil
+}
+
+func (g *GitHubState) PlatformName() string {
+	return "github"
+}
+
+func (g *GitHubState) Init() error {
+	g.initialized = true
+	return nil
+}
+
+func (g *GitHubState) Initted() bool {
+	return g.initialized
+}
+
+func (g *GitHubState) GetState() *BaseState {
+	return g.config.BaseState
+}
+
+// Accepts either a numeric user ID or a login
+func (g *GitHubState) ValidateId(id string) (string, error) {
+	if _, err := strconv.ParseUint(id, 10, 64); err == nil {
+		return id, nil
+	}
+
+	if !githubLoginRegex.MatchString(id) || strings.Contains(id, "--") {
+		return "", errors.New("invalid github id or login")
+	}
+
+	return id, nil
+}
+
+// Logins are aliases of the numeric user ID
+func (g *GitHubState) IsAlias(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err != nil
+}
+
+func (g *GitHubState) PlatformSpecificCache(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
+	return nil, nil
+}
+
+// Returns a error describing the ratelimit if the response is ratelimited, nil otherwise
+func githubRatelimitError(resp *http.Response) error {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return nil
+	}
+
+	// Secondary ratelimits
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		return fmt.Errorf("%w: github ratelimit exceeded, retry after %s seconds", ErrPlatformUnavailable, retryAfter)
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return nil
+	}
+
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+
+	if err != nil {
+		return fmt.Errorf("%w: github ratelimit exceeded", ErrPlatformUnavailable)
+	}
+
+	return fmt.Errorf("%w: github ratelimit exceeded, resets at %s", ErrPlatformUnavailable, time.Unix(reset, 0).UTC().Format(time.RFC3339))
+}
+
+func (g *GitHubState) GetUser(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
+	var reqUrl string
+
+	if _, err := strconv.ParseUint(id, 10, 64); err == nil {
+		reqUrl = g.config.BaseURL + "/user/" + id
+	} else {
+		reqUrl = g.config.BaseURL + "/users/" + id
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqUrl, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	if g.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.config.Token)
+	}
+
+	resp, err := g.config.Client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPlatformUnavailable, err)
+	}
+
+	defer resp.Body.Close()
+
+	if err := githubRatelimitError(resp); err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
+
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("%w: github returned status %d", ErrPlatformUnavailable, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("github returned status %d", resp.StatusCode)
+	}
+
+	var user githubUser
+
+	err = json.NewDecoder(resp.Body).Decode(&user)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode github user: %w", err)
+	}
+
+	return &dovetypes.PlatformUser{
+		ID:          strconv.FormatInt(user.ID, 10),
+		Username:    user.Login,
+		Avatar:      user.AvatarURL,
+		DisplayName: user.Name,
+		Bot:         user.Type == "Bot",
+		Status:      dovetypes.PlatformStatusOffline,
+		Flags:       []string{},
+		ExtraData: map[string]any{
+			"url": user.HTMLURL,
+		},
+	}, nil
+}
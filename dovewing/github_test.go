@@ -0,0 +1,178 @@
+package dovewing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+// Serves octocat (ID 583231) by login and by ID, counting the requests made
+func newGitHubServer(t *testing.T, requests *int64) *httptest.Server {
+	t.Helper()
+
+	const body = `{"id": 583231, "login": "octocat", "name": "The Octocat", "avatar_url": "https://avatars.githubusercontent.com/u/583231", "type": "User"}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(requests, 1)
+
+		switch r.URL.Path {
+		case "/users/octocat", "/user/583231":
+			w.Write([]byte(body))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestGitHubGetUser(t *testing.T) {
+	var requests int64
+
+	srv := newGitHubServer(t, &requests)
+
+	gh, err := dovewing.GitHubStateConfig{BaseURL: srv.URL, BaseState: platformtest.NewState()}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"octocat", "583231"} {
+		u, err := gh.GetUser(context.Background(), id)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.ID != "583231" || u.Username != "octocat" || u.DisplayName != "The Octocat" {
+			t.Fatalf("%s: got %+v", id, u)
+		}
+	}
+
+	if _, err := gh.GetUser(context.Background(), "ghost"); err == nil {
+		t.Fatal("expected an error for a missing user")
+	}
+}
+
+func TestGitHubLoginSharesCacheWithID(t *testing.T) {
+	var requests int64
+
+	srv := newGitHubServer(t, &requests)
+	state := platformtest.NewState()
+
+	gh, err := dovewing.GitHubStateConfig{BaseURL: srv.URL, BaseState: state}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	u, err := dovewing.GetUser(ctx, "octocat", gh)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.ID != "583231" {
+		t.Fatalf("got ID %q, want 583231", u.ID)
+	}
+
+	// Both the login and the ID must now be served from cache
+	for _, id := range []string{"octocat", "583231"} {
+		u, err := dovewing.GetUser(ctx, id, gh)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.ID != "583231" || u.Username != "octocat" {
+			t.Fatalf("%s: got %+v", id, u)
+		}
+	}
+
+	if requests != 1 {
+		t.Fatalf("expected 1 request to github, got %d", requests)
+	}
+
+	if exists, _ := state.PlatformUserCache.Exists(ctx, "github:octocat"); exists {
+		t.Fatal("user cached under login instead of ID")
+	}
+
+	stats := state.Stats.Snapshot()["github"]
+
+	if stats.RedisHits != 2 || stats.PlatformFetches != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestGitHubClearUserByLogin(t *testing.T) {
+	var requests int64
+
+	srv := newGitHubServer(t, &requests)
+	state := platformtest.NewState()
+
+	gh, err := dovewing.GitHubStateConfig{BaseURL: srv.URL, BaseState: state}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := dovewing.GetUser(ctx, "octocat", gh); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dovewing.ClearUser(ctx, "octocat", gh, dovewing.ClearUserReq{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, _ := state.PlatformUserCache.Exists(ctx, "github:583231"); exists {
+		t.Fatal("user still cached after clearing by login")
+	}
+
+	if _, err := dovewing.GetUser(ctx, "octocat", gh); err != nil {
+		t.Fatal(err)
+	}
+
+	if requests != 2 {
+		t.Fatalf("expected the cleared user to be refetched, got %d requests", requests)
+	}
+}
+
+func TestGitHubValidateId(t *testing.T) {
+	gh, err := dovewing.GitHubStateConfig{BaseState: platformtest.NewState()}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, valid := range map[string]bool{
+		"583231":     true,
+		"octocat":    true,
+		"octo-cat":   true,
+		"octo--cat":  false,
+		"-octocat":   false,
+		"octo_cat":   false,
+		"":           false,
+		"octocat/..": false,
+	} {
+		_, err := gh.ValidateId(id)
+
+		if (err == nil) != valid {
+			t.Errorf("%q: got error %v, want valid=%v", id, err, valid)
+		}
+	}
+
+	if !gh.IsAlias("octocat") || gh.IsAlias("583231") {
+		t.Fatal("only logins should be aliases")
+	}
+}
@@ -0,0 +1,122 @@
+package dovewing_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+// Returns a discord platform whose session is in count guilds, g0 to g(count-1), with
+// the user only being a member of the guilds in memberOf. Lookups are counted per guild
+func newGuildScanPlatform(t *testing.T, count int, preferred string, memberOf ...string) (*dovewing.DiscordState, func() map[string]int) {
+	t.Helper()
+
+	session := &discordgo.Session{State: discordgo.NewState()}
+
+	for i := 0; i < count; i++ {
+		if err := session.State.GuildAdd(&discordgo.Guild{ID: "g" + strconv.Itoa(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, err := dovewing.DiscordStateConfig{
+		Session:          session,
+		PreferredGuild:   preferred,
+		BaseState:        platformtest.NewState(),
+		GuildScanWorkers: 4,
+	}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	calls := map[string]int{}
+
+	dovewing.SetMemberLookup(d, func(guildID, userID string) (*discordgo.Member, error) {
+		mu.Lock()
+		calls[guildID]++
+		mu.Unlock()
+
+		for _, g := range memberOf {
+			if g == guildID {
+				return &discordgo.Member{GuildID: guildID, User: &discordgo.User{ID: userID, Username: "member"}}, nil
+			}
+		}
+
+		return nil, discordgo.ErrStateNotFound
+	})
+
+	return d, func() map[string]int {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return calls
+	}
+}
+
+func TestDiscordGuildScanFindsMember(t *testing.T) {
+	d, calls := newGuildScanPlatform(t, 1000, "", "g10")
+
+	u, err := d.PlatformSpecificCache(context.Background(), "42")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u == nil || u.ID != "42" || u.ExtraData["mutual_guild"] != "g10" {
+		t.Fatalf("got %+v, want the member from g10", u)
+	}
+
+	// Once found, the remaining guilds must not be scanned
+	var total int
+
+	for _, n := range calls() {
+		total += n
+	}
+
+	if total >= 1000 {
+		t.Fatalf("scanned %d guilds, expected the scan to stop after finding the member", total)
+	}
+}
+
+func TestDiscordGuildScanNotFound(t *testing.T) {
+	d, calls := newGuildScanPlatform(t, 100, "")
+
+	u, err := d.PlatformSpecificCache(context.Background(), "42")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u != nil {
+		t.Fatalf("got %+v, want no user", u)
+	}
+
+	if got := len(calls()); got != 100 {
+		t.Fatalf("scanned %d guilds, want all 100", got)
+	}
+}
+
+func TestDiscordGuildScanPreferredFirst(t *testing.T) {
+	d, calls := newGuildScanPlatform(t, 100, "g50", "g3", "g50")
+
+	u, err := d.PlatformSpecificCache(context.Background(), "42")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u == nil || u.ExtraData["mutual_guild"] != "g50" || u.ExtraData["preferred_guild"] != true {
+		t.Fatalf("got %+v, want the member from the preferred guild", u)
+	}
+
+	if c := calls(); len(c) != 1 || c["g50"] != 1 {
+		t.Fatalf("expected only the preferred guild to be checked, got %v", c)
+	}
+}
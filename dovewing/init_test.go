@@ -0,0 +1,43 @@
+package dovewing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+func TestSkipTableCreation(t *testing.T) {
+	// Connects lazily, so every statement fails as it would without DDL permissions
+	pool, err := pgxpool.New(context.Background(), "postgres://dovewing@127.0.0.1:1/dovewing?connect_timeout=1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer pool.Close()
+
+	for _, skip := range []bool{false, true} {
+		state := platformtest.NewState()
+		state.Pool = pool
+		state.SkipTableCreation = skip
+
+		p := platformtest.New(state)
+
+		err := dovewing.InitPlatform(p)
+
+		if skip && err != nil {
+			t.Fatalf("init ran DDL despite SkipTableCreation: %s", err)
+		}
+
+		if !skip && err == nil {
+			t.Fatal("expected table creation to fail against the unreachable pool")
+		}
+
+		if skip && !p.Initted() {
+			t.Fatal("platform not initted")
+		}
+	}
+}
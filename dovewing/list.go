@@ -0,0 +1,96 @@
+package dovewing
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+)
+
+// ErrInvalidCursor is returned by ListUsers when the cursor could not be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ListUsers returns a page of users in the internal user cache of a platform ordered by ID
+//
+// Pass an empty cursor to get the first page, then pass the returned cursor to get the next one.
+// nextCursor is empty once there are no more users. Cursors are opaque and stay valid as users are added.
+// Users are returned as stored, without running middlewares
+func ListUsers(ctx context.Context, platform Platform, cursor string, limit int) (users []*dovetypes.PlatformUser, nextCursor string, err error) {
+	if err := ensureInit(platform); err != nil {
+		return nil, "", err
+	}
+
+	if limit <= 0 {
+		return nil, "", errors.New("limit must be positive")
+	}
+
+	var after string
+
+	if cursor != "" {
+		afterBytes, err := base64.RawURLEncoding.DecodeString(cursor)
+
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+
+		after = string(afterBytes)
+	}
+
+	state := platform.GetState()
+
+	if state.Pool == nil {
+		return nil, "", errors.New("listing users requires the internal user cache (BaseState.Pool)")
+	}
+
+	// Fetch one extra row to know if there is a next page
+	rows, err := state.Pool.Query(ctx, "SELECT id, username, display_name, avatar, bot, banner, accent_color FROM "+TableName(platform)+" WHERE id > $1 ORDER BY id LIMIT $2", after, limit+1)
+
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list users: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var username string
+		var displayName string
+		var avatar string
+		var bot bool
+		var banner string
+		var accentColor string
+
+		err = rows.Scan(&id, &username, &displayName, &avatar, &bot, &banner, &accentColor)
+
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan user: %s", err)
+		}
+
+		users = append(users, &dovetypes.PlatformUser{
+			ID:          id,
+			Username:    username,
+			DisplayName: displayName,
+			Avatar:      avatar,
+			Bot:         bot,
+			Banner:      banner,
+			AccentColor: accentColor,
+			Status:      dovetypes.PlatformStatusOffline,
+			ExtraData: map[string]any{
+				"cache": "pg",
+			},
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to list users: %s", err)
+	}
+
+	if len(users) > limit {
+		users = users[:limit]
+		nextCursor = base64.RawURLEncoding.EncodeToString([]byte(users[limit-1].ID))
+	}
+
+	return users, nextCursor, nil
+}
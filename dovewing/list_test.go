@@ -0,0 +1,118 @@
+package dovewing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+func TestListUsersPagination(t *testing.T) {
+	// Keyset pagination is done in SQL, so this needs a real Postgres
+	dsn := os.Getenv("DOVEWING_TEST_POSTGRES")
+
+	if dsn == "" {
+		t.Skip("DOVEWING_TEST_POSTGRES is not set")
+	}
+
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, dsn)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer pool.Close()
+
+	state := platformtest.NewState()
+	state.Pool = pool
+
+	p := platformtest.New(state)
+	p.Name = "listtest"
+
+	if err := dovewing.InitPlatform(p); err != nil {
+		t.Fatal(err)
+	}
+
+	table := dovewing.TableName(p)
+
+	defer pool.Exec(ctx, "DROP TABLE "+table)
+
+	if _, err := pool.Exec(ctx, "DELETE FROM "+table); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("%03d", i)
+
+		_, err := pool.Exec(ctx, "INSERT INTO "+table+" (id, username, display_name, avatar, bot) VALUES ($1, $2, $2, '', false)", id, "user"+id)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := map[string]bool{}
+	var cursor string
+	var pages int
+
+	for {
+		users, next, err := dovewing.ListUsers(ctx, p, cursor, 10)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pages++
+
+		if len(users) != 10 {
+			t.Fatalf("page %d has %d users, want 10", pages, len(users))
+		}
+
+		for _, u := range users {
+			if seen[u.ID] {
+				t.Fatalf("user %s returned on more than one page", u.ID)
+			}
+
+			seen[u.ID] = true
+		}
+
+		if next == "" {
+			break
+		}
+
+		cursor = next
+	}
+
+	if pages != 5 {
+		t.Fatalf("got %d pages, want 5", pages)
+	}
+
+	if len(seen) != 50 {
+		t.Fatalf("saw %d users, want 50", len(seen))
+	}
+}
+
+func TestListUsersErrors(t *testing.T) {
+	p := platformtest.New(nil)
+	ctx := context.Background()
+
+	if _, _, err := dovewing.ListUsers(ctx, p, "", 0); err == nil {
+		t.Fatal("expected an error for a non-positive limit")
+	}
+
+	if _, _, err := dovewing.ListUsers(ctx, p, "not base64!", 10); !errors.Is(err, dovewing.ErrInvalidCursor) {
+		t.Fatalf("got %v, want ErrInvalidCursor", err)
+	}
+
+	// The mock platform has no internal user cache to list
+	if _, _, err := dovewing.ListUsers(ctx, p, "", 10); err == nil {
+		t.Fatal("expected an error without a Pool")
+	}
+}
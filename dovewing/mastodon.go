@@ -0,0 +1,164 @@
+package dovewing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+)
+
+// Matches user@instance handles (the leading @ is stripped before matching)
+var mastodonHandleRegex = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$`)
+
+type mastodonAccount struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	Acct        string `json:"acct"`
+	DisplayName string `json:"display_name"`
+	Avatar      string `json:"avatar"`
+	Bot         bool   `json:"bot"`
+	URL         string `json:"url"`
+}
+
+type MastodonState struct {
+	NoHealthCheck
+	config      *MastodonStateConfig // Config for the mastodon state
+	initialized bool                 // Whether the platform has been initted or not
+}
+
+type MastodonStateConfig struct {
+	InstanceURL string       // Base URL of the instance to query, e.g. https://mastodon.social
+	Client      *http.Client // HTTP client to use, defaults to http.DefaultClient
+	BaseState   *BaseState   // Base state
+}
+
+func (c MastodonStateConfig) New() (*MastodonState, error) {
+	if c.InstanceURL == "" {
+		return nil, errors.New("instance url not provided")
+	}
+
+	if c.BaseState == nil {
+		return nil, errors.New("base state not provided")
+	}
+
+	if c.Client == nil {
+		c.Client = http.DefaultClient
+	}
+
+	c.InstanceURL = strings.TrimSuffix(c.InstanceURL, "/")
+
+	return &MastodonState{
+		config: &c,
+	}, nil
+}
+
+func (m *MastodonState) PlatformName() string {
+	return "mastodon"
+}
+
+func (m *MastodonState) Init() error {
+	m.initialized = true
+	return nil
+}
+
+func (m *MastodonState) Initted() bool {
+	return m.initialized
+}
+
+func (m *MastodonState) GetState() *BaseState {
+	return m.config.BaseState
+}
+
+// Accepts either a numeric account ID or a @user@instance handle
+//
+// Handles are returned without the leading @
+func (m *MastodonState) ValidateId(id string) (string, error) {
+	if _, err := strconv.ParseUint(id, 10, 64); err == nil {
+		return id, nil
+	}
+
+	handle := strings.TrimPrefix(id, "@")
+
+	if !mastodonHandleRegex.MatchString(handle) {
+		return "", errors.New("invalid mastodon id or handle")
+	}
+
+	return handle, nil
+}
+
+// Handles are aliases of the numeric account ID
+func (m *MastodonState) IsAlias(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 64)
+	return err != nil
+}
+
+func (m *MastodonState) PlatformSpecificCache(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
+	return nil, nil
+}
+
+func (m *MastodonState) GetUser(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
+	var reqUrl string
+
+	if strings.Contains(id, "@") {
+		reqUrl = m.config.InstanceURL + "/api/v1/accounts/lookup?acct=" + url.QueryEscape(strings.TrimPrefix(id, "@"))
+	} else {
+		reqUrl = m.config.InstanceURL + "/api/v1/accounts/" + url.PathEscape(id)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqUrl, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := m.config.Client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrPlatformUnavailable, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
+
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("%w: mastodon returned status %d", ErrPlatformUnavailable, resp.StatusCode)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("mastodon returned status %d", resp.StatusCode)
+	}
+
+	var account mastodonAccount
+
+	err = json.NewDecoder(resp.Body).Decode(&account)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode mastodon account: %w", err)
+	}
+
+	return &dovetypes.PlatformUser{
+		ID:          account.ID,
+		Username:    account.Username,
+		Avatar:      account.Avatar,
+		DisplayName: account.DisplayName,
+		Bot:         account.Bot,
+		Status:      dovetypes.PlatformStatusOffline,
+		Flags:       []string{},
+		ExtraData: map[string]any{
+			"acct": account.Acct,
+			"url":  account.URL,
+		},
+	}, nil
+}
@@ -0,0 +1,148 @@
+package dovewing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+func TestMastodonHandleSharesCacheWithID(t *testing.T) {
+	var requests int64
+
+	const body = `{"id": "109302", "username": "gargron", "acct": "Gargron@mastodon.social", "display_name": "Eugen", "avatar": "https://files.mastodon.social/a.png"}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+
+		switch {
+		case r.URL.Path == "/api/v1/accounts/lookup" && r.URL.Query().Get("acct") == "Gargron@mastodon.social":
+			w.Write([]byte(body))
+		case r.URL.Path == "/api/v1/accounts/109302":
+			w.Write([]byte(body))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	state := platformtest.NewState()
+
+	m, err := dovewing.MastodonStateConfig{InstanceURL: srv.URL, BaseState: state}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle, err := m.ValidateId("@Gargron@mastodon.social")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	for _, id := range []string{handle, handle, "109302"} {
+		u, err := dovewing.GetUser(ctx, id, m)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.ID != "109302" {
+			t.Fatalf("%s: got ID %q, want 109302", id, u.ID)
+		}
+	}
+
+	if requests != 1 {
+		t.Fatalf("expected 1 request to mastodon, got %d", requests)
+	}
+
+	if exists, _ := state.PlatformUserCache.Exists(ctx, "mastodon:"+handle); exists {
+		t.Fatal("user cached under handle instead of ID")
+	}
+}
+
+const mastodonAccountBody = `{"id": "109302", "username": "gargron", "acct": "Gargron@mastodon.social", "display_name": "Eugen", "avatar": "https://files.mastodon.social/a.png"}`
+
+func newMastodonState(t *testing.T) *dovewing.MastodonState {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/accounts/lookup" && r.URL.Query().Get("acct") == "Gargron@mastodon.social":
+			w.Write([]byte(mastodonAccountBody))
+		case r.URL.Path == "/api/v1/accounts/109302":
+			w.Write([]byte(mastodonAccountBody))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	m, err := dovewing.MastodonStateConfig{InstanceURL: srv.URL + "/", BaseState: platformtest.NewState()}.New()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestMastodonValidateId(t *testing.T) {
+	m := newMastodonState(t)
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"109302", "109302"},
+		{"@Gargron@mastodon.social", "Gargron@mastodon.social"},
+		{"Gargron@mastodon.social", "Gargron@mastodon.social"},
+		{"first.last@example.co.uk", "first.last@example.co.uk"},
+		{"@Gargron", ""},
+		{"Gargron@localhost", ""},
+		{"bad name@mastodon.social", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := m.ValidateId(tt.id)
+
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %q", tt.id, got)
+			}
+
+			continue
+		}
+
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %q, %v, want %q", tt.id, got, err, tt.want)
+		}
+	}
+}
+
+func TestMastodonGetUser(t *testing.T) {
+	m := newMastodonState(t)
+
+	for _, id := range []string{"109302", "Gargron@mastodon.social"} {
+		u, err := m.GetUser(context.Background(), id)
+
+		if err != nil {
+			t.Fatalf("%s: %s", id, err)
+		}
+
+		if u.ID != "109302" || u.Username != "gargron" || u.DisplayName != "Eugen" || u.Avatar != "https://files.mastodon.social/a.png" {
+			t.Fatalf("%s: unexpected user %+v", id, u)
+		}
+	}
+
+	if _, err := m.GetUser(context.Background(), "404"); err == nil {
+		t.Fatal("expected an error for an unknown account")
+	}
+}
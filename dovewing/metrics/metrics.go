@@ -0,0 +1,72 @@
+// Prometheus metrics for dovewing
+//
+// This package is optional and is not imported by dovewing itself
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/topicbotlist/eureka-port/dovewing"
+)
+
+var (
+	redisHitsDesc = prometheus.NewDesc(
+		"dovewing_redis_hits_total",
+		"Number of users served from the redis cache",
+		[]string{"platform"}, nil,
+	)
+	postgresHitsDesc = prometheus.NewDesc(
+		"dovewing_postgres_hits_total",
+		"Number of users served from the internal user cache",
+		[]string{"platform"}, nil,
+	)
+	platformFetchesDesc = prometheus.NewDesc(
+		"dovewing_platform_fetches_total",
+		"Number of users fetched from the platform",
+		[]string{"platform"}, nil,
+	)
+	backgroundRefreshesDesc = prometheus.NewDesc(
+		"dovewing_background_refreshes_total",
+		"Number of expired users refreshed in the background",
+		[]string{"platform"}, nil,
+	)
+)
+
+type collector struct {
+	states []*dovewing.BaseState
+}
+
+// NewCollector returns a prometheus collector exposing the cumulative cache statistics of the given states
+//
+// If multiple states serve the same platform, their counters are summed
+func NewCollector(states ...*dovewing.BaseState) prometheus.Collector {
+	return &collector{states: states}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisHitsDesc
+	ch <- postgresHitsDesc
+	ch <- platformFetchesDesc
+	ch <- backgroundRefreshesDesc
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	totals := map[string]dovewing.PlatformStats{}
+
+	for _, state := range c.states {
+		for name, ps := range state.Stats.Snapshot() {
+			t := totals[name]
+			t.RedisHits += ps.RedisHits
+			t.PostgresHits += ps.PostgresHits
+			t.PlatformFetches += ps.PlatformFetches
+			t.BackgroundRefreshes += ps.BackgroundRefreshes
+			totals[name] = t
+		}
+	}
+
+	for name, t := range totals {
+		ch <- prometheus.MustNewConstMetric(redisHitsDesc, prometheus.CounterValue, float64(t.RedisHits), name)
+		ch <- prometheus.MustNewConstMetric(postgresHitsDesc, prometheus.CounterValue, float64(t.PostgresHits), name)
+		ch <- prometheus.MustNewConstMetric(platformFetchesDesc, prometheus.CounterValue, float64(t.PlatformFetches), name)
+		ch <- prometheus.MustNewConstMetric(backgroundRefreshesDesc, prometheus.CounterValue, float64(t.BackgroundRefreshes), name)
+	}
+}
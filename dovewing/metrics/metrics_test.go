@@ -0,0 +1,66 @@
+package metrics_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+	"github.com/topicbotlist/eureka-port/dovewing/metrics"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+func TestCollector(t *testing.T) {
+	p := platformtest.New(nil, &dovetypes.PlatformUser{ID: "1", Username: "a"}, &dovetypes.PlatformUser{ID: "2", Username: "b"})
+	ctx := context.Background()
+
+	// Two platform fetches and a cache hit
+	for _, id := range []string{"1", "1", "2"} {
+		if _, err := dovewing.GetUser(ctx, id, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := `
+# HELP dovewing_background_refreshes_total Number of expired users refreshed in the background
+# TYPE dovewing_background_refreshes_total counter
+dovewing_background_refreshes_total{platform="mock"} 0
+# HELP dovewing_platform_fetches_total Number of users fetched from the platform
+# TYPE dovewing_platform_fetches_total counter
+dovewing_platform_fetches_total{platform="mock"} 2
+# HELP dovewing_postgres_hits_total Number of users served from the internal user cache
+# TYPE dovewing_postgres_hits_total counter
+dovewing_postgres_hits_total{platform="mock"} 0
+# HELP dovewing_redis_hits_total Number of users served from the redis cache
+# TYPE dovewing_redis_hits_total counter
+dovewing_redis_hits_total{platform="mock"} 1
+`
+
+	if err := testutil.CollectAndCompare(metrics.NewCollector(p.GetState()), strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectorSumsStates(t *testing.T) {
+	a := platformtest.New(nil, &dovetypes.PlatformUser{ID: "1"})
+	b := platformtest.New(nil, &dovetypes.PlatformUser{ID: "1"})
+	ctx := context.Background()
+
+	for _, p := range []*platformtest.MockPlatform{a, b} {
+		if _, err := dovewing.GetUser(ctx, "1", p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := `
+# HELP dovewing_platform_fetches_total Number of users fetched from the platform
+# TYPE dovewing_platform_fetches_total counter
+dovewing_platform_fetches_total{platform="mock"} 2
+`
+
+	if err := testutil.CollectAndCompare(metrics.NewCollector(a.GetState(), b.GetState()), strings.NewReader(expected), "dovewing_platform_fetches_total"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,134 @@
+// Package platformtest provides an in-memory dovewing platform for testing code that fetches users
+// without needing Postgres, Redis or a real platform
+package platformtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+	"github.com/topicbotlist/eureka-port/hotcache/memory"
+	"go.uber.org/zap"
+)
+
+// NewState returns a BaseState using an in-memory PlatformUserCache and no internal user cache (Pool)
+func NewState() *dovewing.BaseState {
+	return &dovewing.BaseState{
+		Logger:            zap.NewNop(),
+		Context:           context.Background(),
+		PlatformUserCache: memory.New[dovetypes.PlatformUser](),
+		UserExpiryTime:    time.Hour,
+	}
+}
+
+// MockPlatform is a dovewing.Platform serving users from a map
+type MockPlatform struct {
+	dovewing.NoHealthCheck
+
+	// The name of the platform, defaults to mock
+	Name string
+
+	// If set, returned by GetUser instead of looking up the user
+	Err error
+
+	state       *dovewing.BaseState
+	initialized bool
+
+	mu    sync.Mutex
+	users map[string]dovetypes.PlatformUser
+	calls map[string]int
+}
+
+// New returns a mock platform serving the given users. If state is nil, NewState is used
+func New(state *dovewing.BaseState, users ...*dovetypes.PlatformUser) *MockPlatform {
+	if state == nil {
+		state = NewState()
+	}
+
+	m := &MockPlatform{
+		state: state,
+		users: make(map[string]dovetypes.PlatformUser),
+		calls: make(map[string]int),
+	}
+
+	for _, u := range users {
+		m.SetUser(u)
+	}
+
+	return m
+}
+
+// SetUser adds or replaces a user on the platform
+func (m *MockPlatform) SetUser(u *dovetypes.PlatformUser) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users[u.ID] = *u
+}
+
+// RemoveUser removes a user from the platform, later fetches return dovewing.ErrUserNotFound
+func (m *MockPlatform) RemoveUser(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, id)
+}
+
+// Calls returns how many times GetUser was called for the id, useful for asserting caching
+func (m *MockPlatform) Calls(id string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls[id]
+}
+
+func (m *MockPlatform) PlatformName() string {
+	if m.Name == "" {
+		return "mock"
+	}
+
+	return m.Name
+}
+
+func (m *MockPlatform) Init() error {
+	m.initialized = true
+	return nil
+}
+
+func (m *MockPlatform) Initted() bool {
+	return m.initialized
+}
+
+func (m *MockPlatform) GetState() *dovewing.BaseState {
+	return m.state
+}
+
+func (m *MockPlatform) ValidateId(id string) (string, error) {
+	return id, nil
+}
+
+func (m *MockPlatform) PlatformSpecificCache(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
+	return nil, nil
+}
+
+func (m *MockPlatform) GetUser(ctx context.Context, id string) (*dovetypes.PlatformUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls[id]++
+
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	u, ok := m.users[id]
+
+	if !ok {
+		return nil, dovewing.ErrUserNotFound
+	}
+
+	// Copy so callers (and middlewares) can't modify the stored user
+	return &u, nil
+}
@@ -0,0 +1,66 @@
+package platformtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+func TestGetUserAndClearUser(t *testing.T) {
+	p := platformtest.New(nil, &dovetypes.PlatformUser{ID: "1", Username: "alice"})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		u, err := dovewing.GetUser(ctx, "1", p)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.Username != "alice" || u.DisplayName != "alice" {
+			t.Fatalf("got %+v", u)
+		}
+	}
+
+	// Served from the in-memory cache after the first fetch
+	if calls := p.Calls("1"); calls != 1 {
+		t.Fatalf("fetched %d times, want 1", calls)
+	}
+
+	p.SetUser(&dovetypes.PlatformUser{ID: "1", Username: "alicia"})
+
+	if _, err := dovewing.ClearUser(ctx, "1", p, dovewing.ClearUserReq{}); err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := dovewing.GetUser(ctx, "1", p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Username != "alicia" || p.Calls("1") != 2 {
+		t.Fatalf("got %+v after %d fetches, want the updated user refetched", u, p.Calls("1"))
+	}
+}
+
+func TestMockPlatformErrors(t *testing.T) {
+	p := platformtest.New(nil, &dovetypes.PlatformUser{ID: "1", Username: "alice"})
+	ctx := context.Background()
+
+	p.RemoveUser("1")
+
+	if _, err := dovewing.GetUser(ctx, "1", p); !errors.Is(err, dovewing.ErrUserNotFound) {
+		t.Fatalf("removed user: got %v, want ErrUserNotFound", err)
+	}
+
+	p.Err = dovewing.ErrPlatformUnavailable
+
+	if _, err := dovewing.GetUser(ctx, "2", p); !errors.Is(err, dovewing.ErrPlatformUnavailable) {
+		t.Fatalf("got %v, want the configured error", err)
+	}
+}
@@ -0,0 +1,117 @@
+package dovewing_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/topicbotlist/eureka-port/dovewing"
+	"github.com/topicbotlist/eureka-port/dovewing/dovetypes"
+	"github.com/topicbotlist/eureka-port/dovewing/platformtest"
+)
+
+// A mock platform that can also fetch statuses on their own
+type statusPlatform struct {
+	*platformtest.MockPlatform
+
+	mu          sync.Mutex
+	status      dovetypes.PlatformStatus
+	statusCalls int
+}
+
+func (s *statusPlatform) setStatus(status dovetypes.PlatformStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.status = status
+}
+
+func (s *statusPlatform) GetStatus(ctx context.Context, id string) (dovetypes.PlatformStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.statusCalls++
+
+	return s.status, nil
+}
+
+func newStatusPlatform(statusExpiry time.Duration) *statusPlatform {
+	state := platformtest.NewState()
+	state.StatusExpiryTime = statusExpiry
+
+	return &statusPlatform{
+		MockPlatform: platformtest.New(state, &dovetypes.PlatformUser{ID: "1", Username: "user", Status: dovetypes.PlatformStatusOffline}),
+		status:       dovetypes.PlatformStatusOffline,
+	}
+}
+
+func TestRefreshStatus(t *testing.T) {
+	p := newStatusPlatform(time.Minute)
+	ctx := context.Background()
+
+	if _, err := dovewing.GetUser(ctx, "1", p); err != nil {
+		t.Fatal(err)
+	}
+
+	p.setStatus(dovetypes.PlatformStatusOnline)
+
+	status, err := dovewing.RefreshStatus(ctx, "1", p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status != dovetypes.PlatformStatusOnline {
+		t.Fatalf("got status %s, want online", status)
+	}
+
+	if calls := p.Calls("1"); calls != 1 {
+		t.Fatalf("status refresh refetched the profile, %d profile calls", calls)
+	}
+
+	cached, err := p.GetState().PlatformUserCache.Get(ctx, "mock:1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cached.Status != dovetypes.PlatformStatusOnline || cached.Username != "user" {
+		t.Fatalf("unexpected cached user %+v", cached)
+	}
+}
+
+func TestGetUserRefreshesStaleStatusOnly(t *testing.T) {
+	p := newStatusPlatform(50 * time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := dovewing.GetUser(ctx, "1", p); err != nil {
+		t.Fatal(err)
+	}
+
+	// The status is fetched on its own once the profile is cached
+	if _, err := dovewing.GetUser(ctx, "1", p); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	p.setStatus(dovetypes.PlatformStatusIdle)
+
+	u, err := dovewing.GetUser(ctx, "1", p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if u.Status != dovetypes.PlatformStatusIdle {
+		t.Fatalf("got status %s, want idle", u.Status)
+	}
+
+	if calls := p.Calls("1"); calls != 1 {
+		t.Fatalf("stale status refetched the profile, %d profile calls", calls)
+	}
+
+	if p.statusCalls != 2 {
+		t.Fatalf("got %d status calls, want 2", p.statusCalls)
+	}
+}
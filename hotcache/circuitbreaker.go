@@ -0,0 +1,193 @@
+package hotcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned by writes to a CircuitBreakerHotCache while the breaker is open
+var ErrCircuitOpen = errors.New("hot cache circuit breaker is open")
+
+// CircuitBreakerHotCache wraps a HotCache, failing fast once the backend keeps erroring
+//
+// After Threshold consecutive errors the breaker opens. While open, reads behave as a cache miss
+// (Get and Expiry return ErrHotCacheDataNotFound, Exists returns false) so callers fall back to
+// computing the value, and writes return ErrCircuitOpen. After Cooldown, a single call is let through
+// to probe the backend: success closes the breaker, failure opens it for another Cooldown
+type CircuitBreakerHotCache[T any] struct {
+	Cache HotCache[T]
+
+	// Consecutive errors needed to open the breaker, defaults to 5
+	Threshold int
+
+	// How long the breaker stays open before probing the backend, defaults to 30s
+	Cooldown time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreakerHotCache[T any](cache HotCache[T], threshold int, cooldown time.Duration) *CircuitBreakerHotCache[T] {
+	return &CircuitBreakerHotCache[T]{
+		Cache:     cache,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}
+}
+
+// Open returns whether the breaker is currently open (or half-open)
+func (c *CircuitBreakerHotCache[T]) Open() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return !c.openedAt.IsZero()
+}
+
+// Returns whether a call may go through to the backend
+func (c *CircuitBreakerHotCache[T]) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.openedAt.IsZero() {
+		return true
+	}
+
+	cooldown := c.Cooldown
+
+	if cooldown <= 0 {
+		cooldown = defaultBreakerCooldown
+	}
+
+	// Half-open, only one probe at a time
+	if !c.probing && time.Since(c.openedAt) >= cooldown {
+		c.probing = true
+		return true
+	}
+
+	return false
+}
+
+// Records the result of a call that went through to the backend
+func (c *CircuitBreakerHotCache[T]) record(err error) {
+	// Misses and cancellations by the caller say nothing about the backends health
+	if errors.Is(err, ErrHotCacheDataNotFound) || errors.Is(err, context.Canceled) {
+		err = nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		c.failures = 0
+		c.openedAt = time.Time{}
+		c.probing = false
+		return
+	}
+
+	c.failures++
+
+	threshold := c.Threshold
+
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+
+	if c.probing || c.failures >= threshold {
+		c.openedAt = time.Now()
+		c.probing = false
+	}
+}
+
+func (c *CircuitBreakerHotCache[T]) Get(ctx context.Context, key string) (*T, error) {
+	if !c.allow() {
+		return nil, ErrHotCacheDataNotFound
+	}
+
+	v, err := c.Cache.Get(ctx, key)
+	c.record(err)
+	return v, err
+}
+
+func (c *CircuitBreakerHotCache[T]) Delete(ctx context.Context, key string) error {
+	if !c.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := c.Cache.Delete(ctx, key)
+	c.record(err)
+	return err
+}
+
+func (c *CircuitBreakerHotCache[T]) Set(ctx context.Context, key string, value *T, expiry time.Duration) error {
+	if !c.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := c.Cache.Set(ctx, key, value, expiry)
+	c.record(err)
+	return err
+}
+
+func (c *CircuitBreakerHotCache[T]) Increment(ctx context.Context, key string, value int64) error {
+	if !c.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := c.Cache.Increment(ctx, key, value)
+	c.record(err)
+	return err
+}
+
+func (c *CircuitBreakerHotCache[T]) IncrementOne(ctx context.Context, key string) error {
+	if !c.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := c.Cache.IncrementOne(ctx, key)
+	c.record(err)
+	return err
+}
+
+func (c *CircuitBreakerHotCache[T]) Exists(ctx context.Context, key string) (bool, error) {
+	if !c.allow() {
+		return false, nil
+	}
+
+	exists, err := c.Cache.Exists(ctx, key)
+	c.record(err)
+	return exists, err
+}
+
+func (c *CircuitBreakerHotCache[T]) Expiry(ctx context.Context, key string) (time.Duration, error) {
+	if !c.allow() {
+		return 0, ErrHotCacheDataNotFound
+	}
+
+	expiry, err := c.Cache.Expiry(ctx, key)
+	c.record(err)
+	return expiry, err
+}
+
+func (c *CircuitBreakerHotCache[T]) Touch(ctx context.Context, key string, expiry time.Duration) error {
+	if !c.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := c.Cache.Touch(ctx, key, expiry)
+	c.record(err)
+	return err
+}
+
+// Ping always goes through to the backend so readiness probes reflect its health, but doesn't affect the breaker
+func (c *CircuitBreakerHotCache[T]) Ping(ctx context.Context) error {
+	return c.Cache.Ping(ctx)
+}
@@ -0,0 +1,125 @@
+package hotcache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/topicbotlist/eureka-port/hotcache"
+	"github.com/topicbotlist/eureka-port/hotcache/memory"
+)
+
+var errBackendDown = errors.New("backend down")
+
+// Fails every call while down, counting the calls that reached it
+type flakyCache struct {
+	*memory.MemoryHotCache[int]
+
+	down  bool
+	calls int
+}
+
+func (f *flakyCache) Get(ctx context.Context, key string) (*int, error) {
+	f.calls++
+
+	if f.down {
+		return nil, errBackendDown
+	}
+
+	return f.MemoryHotCache.Get(ctx, key)
+}
+
+func (f *flakyCache) Set(ctx context.Context, key string, value *int, expiry time.Duration) error {
+	f.calls++
+
+	if f.down {
+		return errBackendDown
+	}
+
+	return f.MemoryHotCache.Set(ctx, key, value, expiry)
+}
+
+func TestCircuitBreakerTrips(t *testing.T) {
+	backend := &flakyCache{MemoryHotCache: memory.New[int](), down: true}
+	cb := hotcache.NewCircuitBreakerHotCache[int](backend, 3, time.Hour)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := cb.Get(ctx, "key"); !errors.Is(err, errBackendDown) {
+			t.Fatalf("call %d: got %v, want the backend error", i, err)
+		}
+	}
+
+	if !cb.Open() {
+		t.Fatal("breaker still closed after reaching the threshold")
+	}
+
+	// Open breakers fail fast without calling the backend, reads look like misses
+	if _, err := cb.Get(ctx, "key"); !errors.Is(err, hotcache.ErrHotCacheDataNotFound) {
+		t.Fatalf("read: got %v, want ErrHotCacheDataNotFound", err)
+	}
+
+	v := 1
+
+	if err := cb.Set(ctx, "key", &v, time.Minute); !errors.Is(err, hotcache.ErrCircuitOpen) {
+		t.Fatalf("write: got %v, want ErrCircuitOpen", err)
+	}
+
+	if backend.calls != 3 {
+		t.Fatalf("backend called %d times, want 3", backend.calls)
+	}
+}
+
+func TestCircuitBreakerMissesDoNotTrip(t *testing.T) {
+	backend := &flakyCache{MemoryHotCache: memory.New[int]()}
+	cb := hotcache.NewCircuitBreakerHotCache[int](backend, 2, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		cb.Get(context.Background(), "missing")
+	}
+
+	if cb.Open() {
+		t.Fatal("cache misses opened the breaker")
+	}
+}
+
+func TestCircuitBreakerRecovery(t *testing.T) {
+	backend := &flakyCache{MemoryHotCache: memory.New[int](), down: true}
+	cb := hotcache.NewCircuitBreakerHotCache[int](backend, 1, 20*time.Millisecond)
+	ctx := context.Background()
+	v := 1
+
+	cb.Set(ctx, "key", &v, time.Minute)
+
+	if !cb.Open() {
+		t.Fatal("breaker did not open")
+	}
+
+	// A failed probe reopens the breaker for another cooldown
+	time.Sleep(30 * time.Millisecond)
+
+	if err := cb.Set(ctx, "key", &v, time.Minute); !errors.Is(err, errBackendDown) {
+		t.Fatalf("probe: got %v, want the backend error", err)
+	}
+
+	if err := cb.Set(ctx, "key", &v, time.Minute); !errors.Is(err, hotcache.ErrCircuitOpen) {
+		t.Fatalf("after failed probe: got %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it
+	backend.down = false
+	time.Sleep(30 * time.Millisecond)
+
+	if err := cb.Set(ctx, "key", &v, time.Minute); err != nil {
+		t.Fatalf("probe: %s", err)
+	}
+
+	if cb.Open() {
+		t.Fatal("breaker still open after a successful probe")
+	}
+
+	if got, err := cb.Get(ctx, "key"); err != nil || *got != 1 {
+		t.Fatalf("got %v, %v after recovery", got, err)
+	}
+}
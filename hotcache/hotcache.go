@@ -32,6 +32,50 @@ type HotCache[T any] interface {
 
 	// Checks the expiry of a value in the cache
 	Expiry(ctx context.Context, key string) (time.Duration, error)
+
+	// Sets the expiry of a value in the cache without changing the value
+	//
+	// Returns ErrHotCacheDataNotFound if the key does not exist
+	Touch(ctx context.Context, key string, expiry time.Duration) error
+
+	// Checks connectivity to the cache, for use in readiness probes
+	//
+	// Implementations that can't be pinged can embed NoPing
+	Ping(ctx context.Context) error
 }
 
 var ErrHotCacheDataNotFound = errors.New("hot cache data not found")
+
+// NoPing can be embedded in HotCache implementations that have no way of checking connectivity
+//
+// Ping always succeeds
+type NoPing struct{}
+
+func (NoPing) Ping(ctx context.Context) error {
+	return nil
+}
+
+// BatchHotCache is implemented by caches that can set many values at once more efficiently than
+// calling Set for each (e.g. in a single round trip), see SetMany
+type BatchHotCache[T any] interface {
+	HotCache[T]
+
+	// Set many values in the cache with the same expiry
+	SetMany(ctx context.Context, values map[string]*T, expiry time.Duration) error
+}
+
+// SetMany sets many values in the cache, using BatchHotCache.SetMany if the cache implements it
+// and falling back to calling Set for each value otherwise
+func SetMany[T any](ctx context.Context, cache HotCache[T], values map[string]*T, expiry time.Duration) error {
+	if batch, ok := cache.(BatchHotCache[T]); ok {
+		return batch.SetMany(ctx, values, expiry)
+	}
+
+	for key, value := range values {
+		if err := cache.Set(ctx, key, value, expiry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+// In-memory HotCache, useful for tests and single instance deployments
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/topicbotlist/eureka-port/hotcache"
+)
+
+type entry struct {
+	value   []byte
+	expires time.Time // Zero if the entry never expires
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// MemoryHotCache is a HotCache backed by a map, behaving like RedisHotCache
+//
+// Values are stored JSON encoded so callers never share memory with the cache. Expired entries are removed lazily
+type MemoryHotCache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]entry
+
+	hotcache.NoPing
+}
+
+func New[T any]() *MemoryHotCache[T] {
+	return &MemoryHotCache[T]{
+		entries: make(map[string]entry),
+	}
+}
+
+// Returns the entry for key if it exists and has not expired, the lock must be held
+func (m *MemoryHotCache[T]) get(key string) (entry, bool) {
+	e, ok := m.entries[key]
+
+	if !ok {
+		return entry{}, false
+	}
+
+	if e.expired(time.Now()) {
+		delete(m.entries, key)
+		return entry{}, false
+	}
+
+	return e, true
+}
+
+func (m *MemoryHotCache[T]) Get(ctx context.Context, key string) (*T, error) {
+	m.mu.Lock()
+	e, ok := m.get(key)
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, hotcache.ErrHotCacheDataNotFound
+	}
+
+	var val T
+
+	if err := json.Unmarshal(e.value, &val); err != nil {
+		return nil, err
+	}
+
+	return &val, nil
+}
+
+func (m *MemoryHotCache[T]) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+
+	return nil
+}
+
+func (m *MemoryHotCache[T]) Set(ctx context.Context, key string, value *T, expiry time.Duration) error {
+	bytes, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	e := entry{value: bytes}
+
+	// Like redis, a zero expiry means the entry never expires
+	if expiry > 0 {
+		e.expires = time.Now().Add(expiry)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = e
+
+	return nil
+}
+
+func (m *MemoryHotCache[T]) SetMany(ctx context.Context, values map[string]*T, expiry time.Duration) error {
+	for key, value := range values {
+		if err := m.Set(ctx, key, value, expiry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryHotCache[T]) Increment(ctx context.Context, key string, value int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Like INCRBY, missing entries start at zero and the expiry of existing ones is kept
+	e, _ := m.get(key)
+
+	var n int64
+
+	if e.value != nil {
+		var err error
+		n, err = strconv.ParseInt(string(e.value), 10, 64)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	e.value = []byte(strconv.FormatInt(n+value, 10))
+	m.entries[key] = e
+
+	return nil
+}
+
+func (m *MemoryHotCache[T]) IncrementOne(ctx context.Context, key string) error {
+	return m.Increment(ctx, key, 1)
+}
+
+func (m *MemoryHotCache[T]) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.get(key)
+
+	return ok, nil
+}
+
+// Expiry returns the remaining lifetime of the entry, like TTL this is -2 if the entry doesn't exist
+// and -1 if it never expires
+func (m *MemoryHotCache[T]) Expiry(ctx context.Context, key string) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.get(key)
+
+	if !ok {
+		return -2, nil
+	}
+
+	if e.expires.IsZero() {
+		return -1, nil
+	}
+
+	return time.Until(e.expires), nil
+}
+
+func (m *MemoryHotCache[T]) Touch(ctx context.Context, key string, expiry time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.get(key)
+
+	if !ok {
+		return hotcache.ErrHotCacheDataNotFound
+	}
+
+	// Like EXPIRE, a non-positive expiry removes the entry
+	if expiry <= 0 {
+		delete(m.entries, key)
+		return nil
+	}
+
+	e.expires = time.Now().Add(expiry)
+	m.entries[key] = e
+
+	return nil
+}
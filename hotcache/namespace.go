@@ -0,0 +1,89 @@
+package hotcache
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// The separator between a namespace prefix and the rest of the key
+const NamespaceSeparator = ":"
+
+// Namespace is a HotCache that prepends a fixed prefix to every key before passing it on to the underlying cache
+//
+// Two namespaces with different prefixes over the same cache never share keys
+type Namespace[T any] struct {
+	Cache  HotCache[T]
+	Prefix string
+}
+
+func NewNamespace[T any](cache HotCache[T], prefix string) *Namespace[T] {
+	return &Namespace[T]{
+		Cache:  cache,
+		Prefix: prefix,
+	}
+}
+
+// Key builds a key from its parts, joined with NamespaceSeparator
+//
+// The returned key is relative to the namespace and should be passed to the namespaces methods as is
+func (n *Namespace[T]) Key(parts ...string) string {
+	return strings.Join(parts, NamespaceSeparator)
+}
+
+// Sub returns a namespace nested within this one
+func (n *Namespace[T]) Sub(prefix string) *Namespace[T] {
+	return NewNamespace[T](n.Cache, n.key(prefix))
+}
+
+// Returns the full key in the underlying cache
+func (n *Namespace[T]) key(key string) string {
+	return n.Prefix + NamespaceSeparator + key
+}
+
+func (n *Namespace[T]) Get(ctx context.Context, key string) (*T, error) {
+	return n.Cache.Get(ctx, n.key(key))
+}
+
+func (n *Namespace[T]) Delete(ctx context.Context, key string) error {
+	return n.Cache.Delete(ctx, n.key(key))
+}
+
+func (n *Namespace[T]) Set(ctx context.Context, key string, value *T, expiry time.Duration) error {
+	return n.Cache.Set(ctx, n.key(key), value, expiry)
+}
+
+func (n *Namespace[T]) Increment(ctx context.Context, key string, value int64) error {
+	return n.Cache.Increment(ctx, n.key(key), value)
+}
+
+func (n *Namespace[T]) IncrementOne(ctx context.Context, key string) error {
+	return n.Cache.IncrementOne(ctx, n.key(key))
+}
+
+func (n *Namespace[T]) Exists(ctx context.Context, key string) (bool, error) {
+	return n.Cache.Exists(ctx, n.key(key))
+}
+
+func (n *Namespace[T]) Expiry(ctx context.Context, key string) (time.Duration, error) {
+	return n.Cache.Expiry(ctx, n.key(key))
+}
+
+func (n *Namespace[T]) Touch(ctx context.Context, key string, expiry time.Duration) error {
+	return n.Cache.Touch(ctx, n.key(key), expiry)
+}
+
+func (n *Namespace[T]) Ping(ctx context.Context) error {
+	return n.Cache.Ping(ctx)
+}
+
+// SetMany prefixes the keys and sets them through the underlying caches SetMany, see hotcache.SetMany
+func (n *Namespace[T]) SetMany(ctx context.Context, values map[string]*T, expiry time.Duration) error {
+	prefixed := make(map[string]*T, len(values))
+
+	for key, value := range values {
+		prefixed[n.key(key)] = value
+	}
+
+	return SetMany(ctx, n.Cache, prefixed, expiry)
+}
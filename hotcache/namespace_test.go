@@ -0,0 +1,88 @@
+package hotcache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/topicbotlist/eureka-port/hotcache"
+	"github.com/topicbotlist/eureka-port/hotcache/memory"
+)
+
+func TestNamespacesDoNotCollide(t *testing.T) {
+	backend := memory.New[int]()
+	ctx := context.Background()
+
+	ratelimits := hotcache.NewNamespace[int](backend, "ratelimit")
+	users := hotcache.NewNamespace[int](backend, "dovewing")
+
+	one, two := 1, 2
+	key := ratelimits.Key("discord", "123")
+
+	if key != "discord:123" {
+		t.Fatalf("got key %q, want discord:123", key)
+	}
+
+	if err := ratelimits.Set(ctx, key, &one, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := users.Set(ctx, key, &two, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		ns   *hotcache.Namespace[int]
+		want int
+	}{{ratelimits, 1}, {users, 2}} {
+		got, err := tt.ns.Get(ctx, key)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if *got != tt.want {
+			t.Fatalf("%s: got %d, want %d", tt.ns.Prefix, *got, tt.want)
+		}
+	}
+
+	// Keys are prefixed in the backend
+	if got, err := backend.Get(ctx, "ratelimit:discord:123"); err != nil || *got != 1 {
+		t.Fatalf("backend key: got %v, %v", got, err)
+	}
+
+	if err := users.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, _ := ratelimits.Exists(ctx, key); !exists {
+		t.Fatal("deleting from one namespace removed the key from another")
+	}
+
+	if _, err := users.Get(ctx, key); !errors.Is(err, hotcache.ErrHotCacheDataNotFound) {
+		t.Fatalf("got %v, want ErrHotCacheDataNotFound", err)
+	}
+}
+
+func TestNamespaceSub(t *testing.T) {
+	backend := memory.New[int]()
+	ctx := context.Background()
+
+	bucket := hotcache.NewNamespace[int](backend, "ratelimit").Sub("login")
+
+	if err := bucket.IncrementOne(ctx, "1.2.3.4"); err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, _ := backend.Exists(ctx, "ratelimit:login:1.2.3.4"); !exists {
+		t.Fatal("sub namespace key not nested under its parent")
+	}
+}
+
+func TestNoPing(t *testing.T) {
+	// The memory cache embeds NoPing, there is nothing to be unreachable
+	if err := hotcache.NewNamespace[int](memory.New[int](), "test").Ping(context.Background()); err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+}
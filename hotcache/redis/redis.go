@@ -4,14 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/topicbotlist/eureka-port/hotcache"
 	"github.com/redis/go-redis/v9"
+	"github.com/topicbotlist/eureka-port/hotcache"
 )
 
+// RedisHotCache is a HotCache backed by redis
+//
+// Any redis.UniversalClient can be used, so standalone, sentinel (redis.NewFailoverClient) and
+// cluster (redis.NewClusterClient) deployments are all supported. Every operation, including the
+// scripted ones, only touches a single key so no hash tags are needed in cluster mode
 type RedisHotCache[T any] struct {
-	Redis  *redis.Client
+	Redis  redis.UniversalClient
 	Prefix string
 }
 
@@ -51,6 +58,35 @@ func (r RedisHotCache[T]) Set(ctx context.Context, key string, value *T, expiry
 	return r.Redis.Set(ctx, r.Prefix+key, bytes, expiry).Err()
 }
 
+// SetMany sets all values in a single pipelined round trip
+func (r RedisHotCache[T]) SetMany(ctx context.Context, values map[string]*T, expiry time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	encoded := make(map[string][]byte, len(values))
+
+	for key, value := range values {
+		bytes, err := json.Marshal(value)
+
+		if err != nil {
+			return err
+		}
+
+		encoded[key] = bytes
+	}
+
+	_, err := r.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, bytes := range encoded {
+			pipe.Set(ctx, r.Prefix+key, bytes, expiry)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
 func (r RedisHotCache[T]) Increment(ctx context.Context, key string, value int64) error {
 	return r.Redis.IncrBy(ctx, r.Prefix+key, value).Err()
 }
@@ -72,3 +108,124 @@ func (r RedisHotCache[T]) Exists(ctx context.Context, key string) (bool, error)
 func (r RedisHotCache[T]) Expiry(ctx context.Context, key string) (time.Duration, error) {
 	return r.Redis.TTL(ctx, r.Prefix+key).Result()
 }
+
+func (r RedisHotCache[T]) Touch(ctx context.Context, key string, expiry time.Duration) error {
+	ok, err := r.Redis.Expire(ctx, r.Prefix+key, expiry).Result()
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return hotcache.ErrHotCacheDataNotFound
+	}
+
+	return nil
+}
+
+func (r RedisHotCache[T]) Ping(ctx context.Context) error {
+	return r.Redis.Ping(ctx).Err()
+}
+
+// Keys returns all keys matching the glob pattern, with Prefix stripped
+//
+// Uses SCAN so redis is not blocked, this means keys added or removed during the scan may or may not be returned
+func (r RedisHotCache[T]) Keys(ctx context.Context, pattern string) ([]string, error) {
+	// In cluster mode, SCAN only covers the node it is sent to so every master must be scanned
+	if cluster, ok := r.Redis.(*redis.ClusterClient); ok {
+		var mu sync.Mutex
+		var keys []string
+
+		err := cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			nodeKeys, err := r.scanKeys(ctx, client, pattern)
+
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			keys = append(keys, nodeKeys...)
+			mu.Unlock()
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return keys, nil
+	}
+
+	return r.scanKeys(ctx, r.Redis, pattern)
+}
+
+func (r RedisHotCache[T]) scanKeys(ctx context.Context, client redis.Cmdable, pattern string) ([]string, error) {
+	var keys []string
+
+	iter := client.Scan(ctx, 0, r.Prefix+pattern, 0).Iterator()
+
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), r.Prefix))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// KEYS[1] = key, ARGV[1] = "1" if the key must be absent, ARGV[2] = old value, ARGV[3] = new value, ARGV[4] = expiry (ms)
+var casScript = redis.NewScript(`
+local cur = redis.call("GET", KEYS[1])
+
+if ARGV[1] == "1" then
+	if cur then
+		return 0
+	end
+elseif cur ~= ARGV[2] then
+	return 0
+end
+
+if tonumber(ARGV[4]) > 0 then
+	redis.call("SET", KEYS[1], ARGV[3], "PX", ARGV[4])
+else
+	redis.call("SET", KEYS[1], ARGV[3])
+end
+
+return 1
+`)
+
+// CompareAndSwap atomically sets key to new if its current value is old, returning whether the swap happened
+//
+// Values are compared by their JSON serialization. If old is nil, new is only set if the key does not exist
+func (r RedisHotCache[T]) CompareAndSwap(ctx context.Context, key string, old, new *T, expiry time.Duration) (bool, error) {
+	var oldBytes []byte
+	absent := "1"
+
+	if old != nil {
+		var err error
+		oldBytes, err = json.Marshal(old)
+
+		if err != nil {
+			return false, err
+		}
+
+		absent = "0"
+	}
+
+	newBytes, err := json.Marshal(new)
+
+	if err != nil {
+		return false, err
+	}
+
+	swapped, err := casScript.Run(ctx, r.Redis, []string{r.Prefix + key}, absent, oldBytes, newBytes, expiry.Milliseconds()).Int()
+
+	if err != nil {
+		return false, err
+	}
+
+	return swapped == 1, nil
+}
@@ -0,0 +1,304 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/topicbotlist/eureka-port/hotcache"
+	"golang.org/x/exp/slices"
+)
+
+// Returns a cache with the given prefix backed by a fresh miniredis
+func newTestCache[T any](t *testing.T, prefix string) (RedisHotCache[T], *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	t.Cleanup(func() { rdb.Close() })
+
+	return RedisHotCache[T]{Redis: rdb, Prefix: prefix}, mr
+}
+
+func TestTouch(t *testing.T) {
+	c, mr := newTestCache[string](t, "test:")
+	ctx := context.Background()
+
+	value := "session"
+
+	if err := c.Set(ctx, "session", &value, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Touch(ctx, "session", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if ttl := mr.TTL("test:session"); ttl != time.Hour {
+		t.Fatalf("got TTL %s, want 1h", ttl)
+	}
+
+	// The value is untouched
+	got, err := c.Get(ctx, "session")
+
+	if err != nil || *got != value {
+		t.Fatalf("got %v, %v, want %q", got, err, value)
+	}
+
+	if err := c.Touch(ctx, "missing", time.Hour); !errors.Is(err, hotcache.ErrHotCacheDataNotFound) {
+		t.Fatalf("got %v, want ErrHotCacheDataNotFound", err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	c, mr := newTestCache[int](t, "test:")
+	ctx := context.Background()
+
+	for _, key := range []string{"test:rl-users-a", "test:rl-users-b", "test:rl-bots-a", "other:rl-users-c"} {
+		mr.Set(key, "1")
+	}
+
+	keys, err := c.Keys(ctx, "rl-users-*")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(keys)
+
+	if want := []string{"rl-users-a", "rl-users-b"}; !slices.Equal(keys, want) {
+		t.Fatalf("got %v, want %v", keys, want)
+	}
+
+	keys, err = c.Keys(ctx, "nothing-*")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 0 {
+		t.Fatalf("got %v for a pattern with no matches", keys)
+	}
+}
+
+type testObject struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	c, mr := newTestCache[testObject](t, "test:")
+	ctx := context.Background()
+
+	v1 := &testObject{Name: "a", Version: 1}
+	v2 := &testObject{Name: "a", Version: 2}
+	v3 := &testObject{Name: "a", Version: 3}
+
+	if err := c.Set(ctx, "obj", v1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	swapped, err := c.CompareAndSwap(ctx, "obj", v1, v2, time.Minute)
+
+	if err != nil || !swapped {
+		t.Fatalf("swap against the current value failed: %v %v", swapped, err)
+	}
+
+	if got, _ := c.Get(ctx, "obj"); *got != *v2 {
+		t.Fatalf("got %+v after swap, want %+v", got, v2)
+	}
+
+	if ttl := mr.TTL("test:obj"); ttl != time.Minute {
+		t.Fatalf("got TTL %s, want 1m", ttl)
+	}
+
+	// v1 is stale now
+	swapped, err = c.CompareAndSwap(ctx, "obj", v1, v3, time.Minute)
+
+	if err != nil || swapped {
+		t.Fatalf("swap against a changed value succeeded: %v %v", swapped, err)
+	}
+
+	if got, _ := c.Get(ctx, "obj"); *got != *v2 {
+		t.Fatalf("failed swap changed the value to %+v", got)
+	}
+
+	swapped, err = c.CompareAndSwap(ctx, "missing", v1, v2, 0)
+
+	if err != nil || swapped {
+		t.Fatalf("swap against a missing key succeeded: %v %v", swapped, err)
+	}
+
+	if mr.Exists("test:missing") {
+		t.Fatal("failed swap created the key")
+	}
+
+	// A nil old value only sets missing keys
+	swapped, err = c.CompareAndSwap(ctx, "missing", nil, v1, 0)
+
+	if err != nil || !swapped {
+		t.Fatalf("swap with a nil old value against a missing key failed: %v %v", swapped, err)
+	}
+
+	swapped, err = c.CompareAndSwap(ctx, "obj", nil, v1, 0)
+
+	if err != nil || swapped {
+		t.Fatalf("swap with a nil old value against an existing key succeeded: %v %v", swapped, err)
+	}
+}
+
+// miniredis reports itself as a single node cluster holding every slot, which is enough to exercise
+// the cluster client code paths (slot routing, scripts and per-master scans)
+func TestClusterClient(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{mr.Addr()}})
+
+	t.Cleanup(func() { rdb.Close() })
+
+	c := RedisHotCache[int]{Redis: rdb, Prefix: "test:"}
+	ctx := context.Background()
+
+	v := 1
+
+	if err := c.Set(ctx, "counter", &v, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Increment(ctx, "counter", 41); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.Get(ctx, "counter")
+
+	if err != nil || *got != 42 {
+		t.Fatalf("got %v, %v, want 42", got, err)
+	}
+
+	cur, next := 42, 43
+
+	if swapped, err := c.CompareAndSwap(ctx, "counter", &cur, &next, time.Minute); err != nil || !swapped {
+		t.Fatalf("scripted swap failed in cluster mode: %v %v", swapped, err)
+	}
+
+	keys, err := c.Keys(ctx, "*")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(keys, []string{"counter"}) {
+		t.Fatalf("got keys %v, want [counter]", keys)
+	}
+}
+
+func TestPing(t *testing.T) {
+	c, mr := newTestCache[string](t, "test:")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Ping(ctx); err != nil {
+		t.Fatalf("healthy redis failed ping: %s", err)
+	}
+
+	mr.Close()
+
+	if err := c.Ping(ctx); err == nil {
+		t.Fatal("unreachable redis passed ping")
+	}
+}
+
+// Counts the commands sent to redis, and how many of them were pipelined
+type countingHook struct {
+	mu        sync.Mutex
+	pipelines int
+	pipelined map[string]int
+	single    map[string]int
+}
+
+func newCountingHook() *countingHook {
+	return &countingHook{pipelined: map[string]int{}, single: map[string]int{}}
+}
+
+func (h *countingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *countingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.mu.Lock()
+		h.single[cmd.Name()]++
+		h.mu.Unlock()
+
+		return next(ctx, cmd)
+	}
+}
+
+func (h *countingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.mu.Lock()
+		h.pipelines++
+
+		for _, cmd := range cmds {
+			h.pipelined[cmd.Name()]++
+		}
+
+		h.mu.Unlock()
+
+		return next(ctx, cmds)
+	}
+}
+
+func TestSetManyPipelined(t *testing.T) {
+	c, mr := newTestCache[string](t, "test:")
+	ctx := context.Background()
+
+	hook := newCountingHook()
+	c.Redis.AddHook(hook)
+
+	values := map[string]*string{}
+
+	for i := 0; i < 20; i++ {
+		v := strconv.Itoa(i)
+		values["key"+v] = &v
+	}
+
+	if err := c.SetMany(ctx, values, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if hook.pipelines != 1 || hook.pipelined["set"] != 20 || hook.single["set"] != 0 {
+		t.Fatalf("got %d pipelines with %d sets and %d unpipelined sets, want 1 pipeline with 20 sets", hook.pipelines, hook.pipelined["set"], hook.single["set"])
+	}
+
+	for key, want := range values {
+		got, err := mr.Get("test:" + key)
+
+		if err != nil {
+			t.Fatalf("%s not set: %s", key, err)
+		}
+
+		if got != `"`+*want+`"` {
+			t.Fatalf("%s: got %s, want %q", key, got, *want)
+		}
+
+		if ttl := mr.TTL("test:" + key); ttl != time.Minute {
+			t.Fatalf("%s: got ttl %s, want 1m", key, ttl)
+		}
+	}
+
+	// Nothing to send for an empty batch
+	if err := c.SetMany(ctx, nil, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	if hook.pipelines != 1 {
+		t.Fatalf("empty SetMany sent a pipeline")
+	}
+}
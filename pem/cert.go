@@ -0,0 +1,98 @@
+package pem
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"time"
+)
+
+const defaultCertValidity = 365 * 24 * time.Hour
+
+// CertOpts controls the certificate generated by MakeSelfSignedCert
+type CertOpts struct {
+	// The common name of the certificate
+	CommonName string
+
+	// DNS subject alternative names
+	DNSNames []string
+
+	// IP subject alternative names
+	IPAddresses []net.IP
+
+	// How long the certificate is valid for, defaults to one year
+	Validity time.Duration
+
+	// The key to generate for the certificate
+	Key PemOpts
+}
+
+// MakeSelfSignedCert generates a self-signed certificate usable for TLS servers and clients, returning
+// the PEM-encoded certificate and private key
+func MakeSelfSignedCert(opts CertOpts) (certPEM, keyPEM []byte, err error) {
+	key, err := generateKey(opts.Key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	validity := opts.Validity
+
+	if validity == 0 {
+		validity = defaultCertValidity
+	}
+
+	notBefore := time.Now()
+
+	keyUsage := x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign
+
+	// Only RSA keys are used for key encipherment
+	if opts.Key.Algorithm == RSA {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName: opts.CommonName,
+		},
+		DNSNames:              opts.DNSNames,
+		IPAddresses:           opts.IPAddresses,
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(validity),
+		KeyUsage:              keyUsage,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBlock, err := encodePrivateKey(key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	certPEM = pem.EncodeToMemory(
+		&pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: der,
+		},
+	)
+
+	return certPEM, pem.EncodeToMemory(keyBlock), nil
+}
@@ -0,0 +1,80 @@
+package pem
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMakeSelfSignedCert(t *testing.T) {
+	before := time.Now().Truncate(time.Second)
+
+	certPEM, keyPEM, err := MakeSelfSignedCert(CertOpts{
+		CommonName:  "internal.example",
+		DNSNames:    []string{"internal.example", "localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		Validity:    48 * time.Hour,
+		Key:         PemOpts{Algorithm: ECDSA},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+
+	if block == nil || block.Type != "CERTIFICATE" {
+		t.Fatalf("got block %v, want a CERTIFICATE block", block)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cert.Subject.CommonName != "internal.example" {
+		t.Errorf("got common name %q", cert.Subject.CommonName)
+	}
+
+	for _, host := range []string{"internal.example", "localhost", "127.0.0.1", "::1"} {
+		if err := cert.VerifyHostname(host); err != nil {
+			t.Errorf("SAN %s missing: %s", host, err)
+		}
+	}
+
+	if cert.NotBefore.Before(before) || cert.NotBefore.After(time.Now()) {
+		t.Errorf("NotBefore %s is not now", cert.NotBefore)
+	}
+
+	if validity := cert.NotAfter.Sub(cert.NotBefore); validity != 48*time.Hour {
+		t.Errorf("got validity %s, want 48h", validity)
+	}
+
+	// The key must match the certificate
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMakeSelfSignedCertDefaultValidity(t *testing.T) {
+	certPEM, _, err := MakeSelfSignedCert(CertOpts{CommonName: "dev", Key: PemOpts{Algorithm: Ed25519}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if validity := cert.NotAfter.Sub(cert.NotBefore); validity != defaultCertValidity {
+		t.Fatalf("got validity %s, want %s", validity, defaultCertValidity)
+	}
+}
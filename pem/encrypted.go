@@ -0,0 +1,246 @@
+package pem
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Encrypted private keys use PBES2 (RFC 8018) with PBKDF2-HMAC-SHA256 and AES-256-CBC
+// which is understood by OpenSSL and most other tooling
+const (
+	pbkdf2Iterations = 600000
+	pbkdf2SaltSize   = 16
+	aes256KeySize    = 32
+)
+
+// Keys are untrusted input, so higher iteration counts are rejected to stop crafted keys pinning a CPU
+const maxPBKDF2Iterations = 10000000
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase or corrupt private key")
+
+type encryptedPrivateKeyInfo struct {
+	Algo          pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// MakeEncryptedPem generates a key pair like MakePemWithOpts, but returns the private key as a
+// passphrase-encrypted PKCS#8 (ENCRYPTED PRIVATE KEY) block
+func MakeEncryptedPem(passphrase []byte, opts PemOpts) (privPEM, pubPEM []byte, err error) {
+	key, err := generateKey(opts)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBlock, err := encryptPrivateKey(key, passphrase)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pubBlock, err := encodePublicKey(key.Public())
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pem.EncodeToMemory(keyBlock), pem.EncodeToMemory(pubBlock), nil
+}
+
+func encryptPrivateKey(key crypto.Signer, passphrase []byte) (*pem.Block, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	salt := make([]byte, pbkdf2SaltSize)
+	iv := make([]byte, aes.BlockSize)
+
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(pbkdf2.Key(passphrase, salt, pbkdf2Iterations, aes256KeySize, sha256.New))
+
+	if err != nil {
+		return nil, err
+	}
+
+	// PKCS#7 padding
+	padding := aes.BlockSize - len(der)%aes.BlockSize
+	plaintext := append(der, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	encrypted := make([]byte, len(plaintext))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plaintext)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pbkdf2Iterations,
+		PRF: pkix.AlgorithmIdentifier{
+			Algorithm:  oidHMACWithSHA256,
+			Parameters: asn1.NullRawValue,
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	ivParams, err := asn1.Marshal(iv)
+
+	if err != nil {
+		return nil, err
+	}
+
+	schemeParams, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBKDF2,
+			Parameters: asn1.RawValue{FullBytes: kdfParams},
+		},
+		EncryptionScheme: pkix.AlgorithmIdentifier{
+			Algorithm:  oidAES256CBC,
+			Parameters: asn1.RawValue{FullBytes: ivParams},
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algo: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPBES2,
+			Parameters: asn1.RawValue{FullBytes: schemeParams},
+		},
+		EncryptedData: encrypted,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pem.Block{
+		Type:  "ENCRYPTED PRIVATE KEY",
+		Bytes: info,
+	}, nil
+}
+
+// ParseEncryptedPrivateKey decrypts and parses a PEM-encoded ENCRYPTED PRIVATE KEY block
+//
+// Only PBES2 with PBKDF2 (HMAC-SHA256) and AES-256-CBC is supported, as emitted by MakeEncryptedPem
+func ParseEncryptedPrivateKey(pemBytes []byte, passphrase []byte) (crypto.PrivateKey, error) {
+	block, err := decodeBlock(pemBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type != "ENCRYPTED PRIVATE KEY" {
+		return nil, fmt.Errorf("unsupported encrypted private key block type: %s", block.Type)
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %w", err)
+	}
+
+	if !info.Algo.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption algorithm: %s", info.Algo.Algorithm)
+	}
+
+	var scheme pbes2Params
+	if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &scheme); err != nil {
+		return nil, fmt.Errorf("failed to parse PBES2 parameters: %w", err)
+	}
+
+	if !scheme.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", scheme.KeyDerivationFunc.Algorithm)
+	}
+
+	if !scheme.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+		return nil, fmt.Errorf("unsupported encryption scheme: %s", scheme.EncryptionScheme.Algorithm)
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(scheme.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("failed to parse PBKDF2 parameters: %w", err)
+	}
+
+	if kdf.IterationCount <= 0 || kdf.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("unsupported PBKDF2 iteration count: %d", kdf.IterationCount)
+	}
+
+	if kdf.PRF.Algorithm != nil && !kdf.PRF.Algorithm.Equal(oidHMACWithSHA256) {
+		return nil, fmt.Errorf("unsupported PBKDF2 PRF: %s", kdf.PRF.Algorithm)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(scheme.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("failed to parse AES parameters: %w", err)
+	}
+
+	if len(iv) != aes.BlockSize || len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, ErrIncorrectPassphrase
+	}
+
+	c, err := aes.NewCipher(pbkdf2.Key(passphrase, kdf.Salt, kdf.IterationCount, aes256KeySize, sha256.New))
+
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(plaintext, info.EncryptedData)
+
+	// Strip and check PKCS#7 padding
+	padding := int(plaintext[len(plaintext)-1])
+
+	if padding == 0 || padding > aes.BlockSize || !hmac.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrIncorrectPassphrase
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(plaintext[:len(plaintext)-padding])
+
+	if err != nil {
+		return nil, ErrIncorrectPassphrase
+	}
+
+	return key, nil
+}
@@ -0,0 +1,103 @@
+package pem
+
+import (
+	"crypto/ed25519"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Re-encodes an encrypted key with a different PBKDF2 iteration count
+func withIterationCount(t *testing.T, privPEM []byte, iterations int) []byte {
+	t.Helper()
+
+	block, _ := pem.Decode(privPEM)
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		t.Fatal(err)
+	}
+
+	var scheme pbes2Params
+	if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(scheme.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		t.Fatal(err)
+	}
+
+	kdf.IterationCount = iterations
+
+	kdfParams, err := asn1.Marshal(kdf)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scheme.KeyDerivationFunc.Parameters = asn1.RawValue{FullBytes: kdfParams}
+
+	schemeParams, err := asn1.Marshal(scheme)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info.Algo.Parameters = asn1.RawValue{FullBytes: schemeParams}
+
+	der, err := asn1.Marshal(info)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der})
+}
+
+func TestEncryptedPrivateKeyRoundTrip(t *testing.T) {
+	privPEM, _, err := MakeEncryptedPem([]byte("hunter2"), PemOpts{Algorithm: Ed25519})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ParseEncryptedPrivateKey(privPEM, []byte("hunter2"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := key.(ed25519.PrivateKey); !ok {
+		t.Fatalf("got %T, want ed25519.PrivateKey", key)
+	}
+
+	if _, err := ParseEncryptedPrivateKey(privPEM, []byte("wrong")); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Fatalf("got %v, want ErrIncorrectPassphrase", err)
+	}
+}
+
+func TestEncryptedPrivateKeyIterationBounds(t *testing.T) {
+	privPEM, _, err := MakeEncryptedPem([]byte("hunter2"), PemOpts{Algorithm: Ed25519})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, iterations := range []int{0, -1, maxPBKDF2Iterations + 1, 1<<31 - 1} {
+		_, err := ParseEncryptedPrivateKey(withIterationCount(t, privPEM, iterations), []byte("hunter2"))
+
+		if err == nil || !strings.Contains(err.Error(), "iteration count") {
+			t.Errorf("%d iterations: got %v, want an iteration count error", iterations, err)
+		}
+	}
+
+	// Counts within the bounds are still derived, so the passphrase no longer matches
+	_, err = ParseEncryptedPrivateKey(withIterationCount(t, privPEM, 1), []byte("hunter2"))
+
+	if !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Fatalf("got %v, want ErrIncorrectPassphrase", err)
+	}
+}
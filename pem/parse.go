@@ -0,0 +1,74 @@
+package pem
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+var ErrNoPemBlock = errors.New("no PEM block found")
+
+func decodeBlock(pemBytes []byte) (*pem.Block, error) {
+	block, _ := pem.Decode(pemBytes)
+
+	if block == nil {
+		return nil, ErrNoPemBlock
+	}
+
+	return block, nil
+}
+
+// ParsePrivateKey parses a PEM-encoded private key, detecting the format from the block type
+//
+// PKCS#1 (RSA PRIVATE KEY), SEC 1 (EC PRIVATE KEY) and PKCS#8 (PRIVATE KEY) are supported
+func ParsePrivateKey(pemBytes []byte) (crypto.PrivateKey, error) {
+	block, err := decodeBlock(pemBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parsePrivateKeyBlock(block)
+}
+
+func parsePrivateKeyBlock(block *pem.Block) (crypto.PrivateKey, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	return nil, fmt.Errorf("unsupported private key block type: %s", block.Type)
+}
+
+// ParsePublicKey parses a PEM-encoded public key, detecting the format from the block type
+//
+// PKIX (PUBLIC KEY) and PKCS#1 (RSA PUBLIC KEY) are supported. As older versions of MakePem
+// labelled PKIX keys as RSA PUBLIC KEY, these are also accepted
+func ParsePublicKey(pemBytes []byte) (crypto.PublicKey, error) {
+	block, err := decodeBlock(pemBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+
+		if err == nil {
+			return pub, nil
+		}
+
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+
+	return nil, fmt.Errorf("unsupported public key block type: %s", block.Type)
+}
@@ -0,0 +1,97 @@
+package pem
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+func TestParseKeyFormats(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkix, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privBlocks := map[string]*pem.Block{
+		"pkcs1": {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)},
+		"pkcs8": {Type: "PRIVATE KEY", Bytes: pkcs8},
+	}
+
+	for name, block := range privBlocks {
+		priv, err := ParsePrivateKey(pem.EncodeToMemory(block))
+
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if !key.Equal(priv) {
+			t.Fatalf("%s: parsed key does not match", name)
+		}
+	}
+
+	pubBlocks := map[string]*pem.Block{
+		"pkix":   {Type: "PUBLIC KEY", Bytes: pkix},
+		"pkcs1":  {Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)},
+		"legacy": {Type: "RSA PUBLIC KEY", Bytes: pkix},
+	}
+
+	for name, block := range pubBlocks {
+		pub, err := ParsePublicKey(pem.EncodeToMemory(block))
+
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if !key.PublicKey.Equal(pub) {
+			t.Fatalf("%s: parsed key does not match", name)
+		}
+	}
+}
+
+func TestParseMalformedPem(t *testing.T) {
+	if _, err := ParsePrivateKey([]byte("not a pem")); !errors.Is(err, ErrNoPemBlock) {
+		t.Fatalf("got %v, want ErrNoPemBlock", err)
+	}
+
+	if _, err := ParsePublicKey(nil); !errors.Is(err, ErrNoPemBlock) {
+		t.Fatalf("got %v, want ErrNoPemBlock", err)
+	}
+
+	unknown := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{1, 2, 3}})
+
+	if _, err := ParsePrivateKey(unknown); err == nil {
+		t.Fatal("parsed a certificate as a private key")
+	}
+
+	if _, err := ParsePublicKey(unknown); err == nil {
+		t.Fatal("parsed a certificate as a public key")
+	}
+
+	corrupt := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("garbage")})
+
+	if _, err := ParsePrivateKey(corrupt); err == nil {
+		t.Fatal("parsed a corrupt private key")
+	}
+
+	corrupt = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("garbage")})
+
+	if _, err := ParsePublicKey(corrupt); err == nil {
+		t.Fatal("parsed a corrupt public key")
+	}
+}
@@ -1,45 +1,156 @@
 package pem
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 )
 
 const bitSize = 4096
 
+// The key algorithm to use when generating a key pair
+type Algorithm int
+
+const (
+	RSA Algorithm = iota
+	ECDSA
+	Ed25519
+)
+
+// Returns the algorithm as a string
+func (a Algorithm) String() string {
+	switch a {
+	case RSA:
+		return "RSA"
+	case ECDSA:
+		return "ECDSA"
+	case Ed25519:
+		return "Ed25519"
+	}
+
+	return fmt.Sprintf("Algorithm(%d)", int(a))
+}
+
+// PemOpts controls the key pair generated by MakePemWithOpts
+type PemOpts struct {
+	// The algorithm to use, defaults to RSA
+	Algorithm Algorithm
+
+	// The RSA key size in bits, defaults to 4096. Only used for RSA
+	Bits int
+
+	// The curve to use, defaults to P-256. Only used for ECDSA
+	Curve elliptic.Curve
+}
+
 func MakePem() ([]byte, []byte, error) {
-	key, err := rsa.GenerateKey(rand.Reader, bitSize)
+	return MakePemWithOpts(PemOpts{Algorithm: RSA, Bits: bitSize})
+}
+
+// MakePemWithOpts generates a key pair based on the given options, returning the PEM-encoded private and public keys
+func MakePemWithOpts(opts PemOpts) ([]byte, []byte, error) {
+	key, err := generateKey(opts)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyBlock, err := encodePrivateKey(key)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pubBlock, err := encodePublicKey(key.Public())
+
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// Extract public component.
-	pub := key.Public()
+	return pem.EncodeToMemory(keyBlock), pem.EncodeToMemory(pubBlock), nil
+}
+
+func generateKey(opts PemOpts) (crypto.Signer, error) {
+	switch opts.Algorithm {
+	case RSA:
+		bits := opts.Bits
+
+		if bits == 0 {
+			bits = bitSize
+		}
 
-	// Encode private key to PKCS#1 ASN.1 PEM.
-	keyPEM := pem.EncodeToMemory(
-		&pem.Block{
+		return rsa.GenerateKey(rand.Reader, bits)
+	case ECDSA:
+		curve := opts.Curve
+
+		if curve == nil {
+			curve = elliptic.P256()
+		}
+
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case Ed25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return key, nil
+	}
+
+	return nil, errors.New("unsupported algorithm: " + opts.Algorithm.String())
+}
+
+func encodePrivateKey(key crypto.Signer) (*pem.Block, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		// Encode private key to PKCS#1 ASN.1 PEM.
+		return &pem.Block{
 			Type:  "RSA PRIVATE KEY",
 			Bytes: x509.MarshalPKCS1PrivateKey(key),
-		},
-	)
+		}, nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(key)
 
-	pk, err := x509.MarshalPKIXPublicKey(pub.(*rsa.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal private key: %w", err)
+		}
 
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal public key: %w", err)
+		return &pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: der,
+		}, nil
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal private key: %w", err)
+		}
+
+		return &pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: der,
+		}, nil
 	}
+}
+
+func encodePublicKey(pub crypto.PublicKey) (*pem.Block, error) {
+	pk, err := x509.MarshalPKIXPublicKey(pub)
 
-	// Encode public key to PKCS#1 ASN.1 PEM.
-	pubPEM := pem.EncodeToMemory(
-		&pem.Block{
-			Type:  "RSA PUBLIC KEY",
-			Bytes: pk,
-		},
-	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %w", err)
+	}
 
-	return keyPEM, pubPEM, nil
+	// Encode public key to PKIX (SubjectPublicKeyInfo) ASN.1 PEM.
+	return &pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pk,
+	}, nil
 }
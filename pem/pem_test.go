@@ -0,0 +1,143 @@
+package pem
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+// Decodes a private key in any of the formats written by MakePemWithOpts
+func parsePrivateKey(t *testing.T, data []byte) any {
+	t.Helper()
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		t.Fatal("no PEM block found")
+	}
+
+	var key any
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func parsePublicKey(t *testing.T, data []byte) any {
+	t.Helper()
+
+	block, _ := pem.Decode(data)
+
+	if block == nil {
+		t.Fatal("no PEM block found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func TestMakePemWithOpts(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  PemOpts
+		check func(t *testing.T, priv, pub any)
+	}{
+		{"rsa", PemOpts{Algorithm: RSA, Bits: 2048}, func(t *testing.T, priv, pub any) {
+			key, ok := priv.(*rsa.PrivateKey)
+
+			if !ok || key.N.BitLen() != 2048 {
+				t.Fatalf("got %T, want a 2048 bit RSA key", priv)
+			}
+
+			if !key.PublicKey.Equal(pub) {
+				t.Fatal("public key does not match")
+			}
+		}},
+		{"ecdsa default curve", PemOpts{Algorithm: ECDSA}, func(t *testing.T, priv, pub any) {
+			key, ok := priv.(*ecdsa.PrivateKey)
+
+			if !ok || key.Curve != elliptic.P256() {
+				t.Fatalf("got %T, want a P-256 ECDSA key", priv)
+			}
+
+			if !key.PublicKey.Equal(pub) {
+				t.Fatal("public key does not match")
+			}
+		}},
+		{"ecdsa p384", PemOpts{Algorithm: ECDSA, Curve: elliptic.P384()}, func(t *testing.T, priv, pub any) {
+			if key, ok := priv.(*ecdsa.PrivateKey); !ok || key.Curve != elliptic.P384() {
+				t.Fatalf("got %T, want a P-384 ECDSA key", priv)
+			}
+		}},
+		{"ed25519", PemOpts{Algorithm: Ed25519}, func(t *testing.T, priv, pub any) {
+			key, ok := priv.(ed25519.PrivateKey)
+
+			if !ok {
+				t.Fatalf("got %T, want an Ed25519 key", priv)
+			}
+
+			if !key.Public().(ed25519.PublicKey).Equal(pub) {
+				t.Fatal("public key does not match")
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			privPEM, pubPEM, err := MakePemWithOpts(tt.opts)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.check(t, parsePrivateKey(t, privPEM), parsePublicKey(t, pubPEM))
+		})
+	}
+}
+
+func TestMakePemWithOptsUnsupportedAlgorithm(t *testing.T) {
+	if _, _, err := MakePemWithOpts(PemOpts{Algorithm: Algorithm(42)}); err == nil {
+		t.Fatal("expected an error for an unknown algorithm")
+	}
+}
+
+func TestPublicKeyIsPKIX(t *testing.T) {
+	for _, alg := range []Algorithm{RSA, ECDSA, Ed25519} {
+		_, pubPEM, err := MakePemWithOpts(PemOpts{Algorithm: alg, Bits: 2048})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		block, _ := pem.Decode(pubPEM)
+
+		if block == nil || block.Type != "PUBLIC KEY" {
+			t.Fatalf("%s: got block %v, want a PUBLIC KEY block", alg, block)
+		}
+
+		if _, err := x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			t.Fatalf("%s: %s", alg, err)
+		}
+	}
+}
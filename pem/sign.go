@@ -0,0 +1,71 @@
+package pem
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Sign signs data using a PEM-encoded private key
+//
+// RSA keys use RSA-PSS with SHA-256, ECDSA keys use ASN.1 encoded signatures over a
+// SHA-256 digest and Ed25519 keys sign the data directly
+func Sign(privPEM []byte, data []byte) ([]byte, error) {
+	key, err := ParsePrivateKey(privPEM)
+
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(data)
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest[:], nil)
+	case *ecdsa.PrivateKey:
+		return ecdsa.SignASN1(rand.Reader, key, digest[:])
+	case ed25519.PrivateKey:
+		return ed25519.Sign(key, data), nil
+	}
+
+	return nil, fmt.Errorf("unsupported private key type: %T", key)
+}
+
+// Verify verifies a signature created by Sign using a PEM-encoded public key
+//
+// Returns ErrInvalidSignature if the signature does not match
+func Verify(pubPEM []byte, data, sig []byte) error {
+	key, err := ParsePublicKey(pubPEM)
+
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(data)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if rsa.VerifyPSS(key, crypto.SHA256, digest[:], sig, nil) != nil {
+			return ErrInvalidSignature
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return ErrInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, data, sig) {
+			return ErrInvalidSignature
+		}
+	default:
+		return fmt.Errorf("unsupported public key type: %T", key)
+	}
+
+	return nil
+}
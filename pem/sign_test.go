@@ -0,0 +1,44 @@
+package pem
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	data := []byte("the quick brown fox")
+
+	for _, alg := range []Algorithm{RSA, ECDSA, Ed25519} {
+		t.Run(alg.String(), func(t *testing.T) {
+			privPEM, pubPEM, err := MakePemWithOpts(PemOpts{Algorithm: alg, Bits: 2048})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sig, err := Sign(privPEM, data)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := Verify(pubPEM, data, sig); err != nil {
+				t.Fatalf("valid signature rejected: %s", err)
+			}
+
+			if err := Verify(pubPEM, []byte("the quick brown cat"), sig); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("signature over other data: got %v, want ErrInvalidSignature", err)
+			}
+
+			_, otherPubPEM, err := MakePemWithOpts(PemOpts{Algorithm: alg, Bits: 2048})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := Verify(otherPubPEM, data, sig); !errors.Is(err, ErrInvalidSignature) {
+				t.Fatalf("mismatched key: got %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
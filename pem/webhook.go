@@ -0,0 +1,64 @@
+package pem
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+)
+
+// Parses an Ed25519 public key given as PEM, raw bytes or hex (as shown in the Discord developer portal)
+func parseEd25519PublicKey(publicKey []byte) (ed25519.PublicKey, bool) {
+	// Raw keys are checked before trimming, as their first or last byte may be whitespace
+	if len(publicKey) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(publicKey), true
+	}
+
+	publicKey = bytes.TrimSpace(publicKey)
+
+	if bytes.HasPrefix(publicKey, []byte("-----BEGIN")) {
+		pub, err := ParsePublicKey(publicKey)
+
+		if err != nil {
+			return nil, false
+		}
+
+		key, ok := pub.(ed25519.PublicKey)
+		return key, ok
+	}
+
+	if len(publicKey) == hex.EncodedLen(ed25519.PublicKeySize) {
+		key, err := hex.DecodeString(string(publicKey))
+
+		if err != nil {
+			return nil, false
+		}
+
+		return ed25519.PublicKey(key), true
+	}
+
+	return nil, false
+}
+
+// VerifyEd25519Signature verifies a Discord-style Ed25519 signed webhook
+//
+// The signature (X-Signature-Ed25519, hex encoded) is over the timestamp (X-Signature-Timestamp) followed by the
+// raw body. publicKey may be PEM, raw bytes or hex encoded
+func VerifyEd25519Signature(publicKey []byte, timestamp string, body []byte, signatureHex string) bool {
+	key, ok := parseEd25519PublicKey(publicKey)
+
+	if !ok {
+		return false
+	}
+
+	sig, err := hex.DecodeString(signatureHex)
+
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+
+	msg := make([]byte, 0, len(timestamp)+len(body))
+	msg = append(msg, timestamp...)
+	msg = append(msg, body...)
+
+	return ed25519.Verify(key, msg, sig)
+}
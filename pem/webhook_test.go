@@ -0,0 +1,92 @@
+package pem
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+)
+
+// Key derived from the seed 0x00..0x1f, signing the timestamp 1700000000 and the body {"type":1}
+const (
+	webhookPublicKeyHex = "03a107bff3ce10be1d70dd18e74bc09967e4d6309ba50d5f1ddc8664125531b8"
+	webhookPublicKeyPEM = `-----BEGIN PUBLIC KEY-----
+MCowBQYDK2VwAyEAA6EHv/POEL4dcN0Y50vAmWfk1jCbpQ1fHdyGZBJVMbg=
+-----END PUBLIC KEY-----
+`
+	webhookTimestamp = "1700000000"
+	webhookBody      = `{"type":1}`
+	webhookSignature = "c1098e97d711377f30225d53d94b89d43537f92e5b3afaddc590781b1f9f9d4b2eeab335d370be3b9a090bc61a85b86448bc140dcd195f1569c2bff181257607"
+)
+
+func TestVerifyEd25519Signature(t *testing.T) {
+	rawKey, err := hex.DecodeString(webhookPublicKeyHex)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := map[string][]byte{
+		"hex": []byte(webhookPublicKeyHex),
+		"pem": []byte(webhookPublicKeyPEM),
+		"raw": rawKey,
+	}
+
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			if !VerifyEd25519Signature(key, webhookTimestamp, []byte(webhookBody), webhookSignature) {
+				t.Fatal("valid signature rejected")
+			}
+
+			if VerifyEd25519Signature(key, webhookTimestamp, []byte(`{"type":2}`), webhookSignature) {
+				t.Fatal("signature accepted for a tampered body")
+			}
+
+			if VerifyEd25519Signature(key, "1700000001", []byte(webhookBody), webhookSignature) {
+				t.Fatal("signature accepted for a different timestamp")
+			}
+		})
+	}
+}
+
+func TestVerifyEd25519SignatureMalformed(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		signature string
+	}{
+		{"signature not hex", webhookPublicKeyHex, "zz" + webhookSignature[2:]},
+		{"short signature", webhookPublicKeyHex, webhookSignature[:64]},
+		{"short key", webhookPublicKeyHex[:32], webhookSignature},
+		{"bad pem", "-----BEGIN PUBLIC KEY-----\nnope\n-----END PUBLIC KEY-----", webhookSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if VerifyEd25519Signature([]byte(tt.key), webhookTimestamp, []byte(webhookBody), tt.signature) {
+				t.Fatal("malformed input accepted")
+			}
+		})
+	}
+}
+
+// Raw keys are arbitrary bytes, so one ending in a space must not be trimmed
+func TestVerifyEd25519SignatureRawWhitespace(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+
+	var priv ed25519.PrivateKey
+
+	for i := 0; ; i++ {
+		seed[0], seed[1] = byte(i), byte(i>>8)
+		priv = ed25519.NewKeyFromSeed(seed)
+
+		if priv.Public().(ed25519.PublicKey)[ed25519.PublicKeySize-1] == ' ' {
+			break
+		}
+	}
+
+	sig := hex.EncodeToString(ed25519.Sign(priv, []byte(webhookTimestamp+webhookBody)))
+
+	if !VerifyEd25519Signature(priv.Public().(ed25519.PublicKey), webhookTimestamp, []byte(webhookBody), sig) {
+		t.Fatal("valid signature rejected for a raw key ending in whitespace")
+	}
+}
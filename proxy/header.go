@@ -0,0 +1,73 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Hop-by-hop headers as defined by RFC 7230, these should not be forwarded by proxies
+var HopByHopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// HeaderRewriter sets and removes request headers before passing the request on to the next round tripper
+//
+// Hop-by-hop headers (see HopByHopHeaders) and any headers listed in Connection are always removed, before Set is
+// applied. All other headers are passed through unchanged. The callers request is never modified
+type HeaderRewriter struct {
+	next http.RoundTripper
+
+	// Headers to set, replacing any existing values
+	Set map[string]string
+
+	// Headers to remove, applied before Set
+	Remove []string
+}
+
+func NewHeaderRewriter(set map[string]string, remove []string, next http.RoundTripper) HeaderRewriter {
+	return HeaderRewriter{
+		next:   next,
+		Set:    set,
+		Remove: remove,
+	}
+}
+
+func (rt HeaderRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Clone deep copies the headers, so retries of the original request are unaffected
+	newReq := req.Clone(req.Context())
+
+	removeHopByHop(newReq.Header)
+
+	for _, h := range rt.Remove {
+		newReq.Header.Del(h)
+	}
+
+	for k, v := range rt.Set {
+		newReq.Header.Set(k, v)
+	}
+
+	return rt.next.RoundTrip(newReq)
+}
+
+// Removes the hop-by-hop headers and the headers listed in Connection from h
+func removeHopByHop(h http.Header) {
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+
+	for _, name := range HopByHopHeaders {
+		h.Del(name)
+	}
+}
@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderRewriter(t *testing.T) {
+	next := &recordingTransport{}
+
+	rt := NewHeaderRewriter(map[string]string{"Authorization": "Bearer upstream"}, []string{"Cookie"}, next)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	req.Header.Set("Authorization", "Bearer client")
+	req.Header.Set("Cookie", "session=1")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Connection", "keep-alive, X-Hop")
+	req.Header.Set("X-Hop", "1")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Proxy-Authorization", "Basic Zm9vOmJhcg==")
+	req.Header.Set("Keep-Alive", "timeout=5")
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := next.req.Header
+
+	if v := got.Get("Authorization"); v != "Bearer upstream" {
+		t.Errorf("Authorization not injected, got %q", v)
+	}
+
+	if v := got.Get("Accept"); v != "application/json" {
+		t.Errorf("Accept not passed through, got %q", v)
+	}
+
+	for _, h := range []string{"Cookie", "Connection", "X-Hop", "Upgrade", "Proxy-Authorization", "Keep-Alive"} {
+		if v := got.Get(h); v != "" {
+			t.Errorf("%s should have been removed, got %q", h, v)
+		}
+	}
+
+	// The callers request is untouched
+	if req.Header.Get("Authorization") != "Bearer client" || req.Header.Get("Connection") == "" || req.Header.Get("Cookie") == "" {
+		t.Errorf("callers request was modified: %v", req.Header)
+	}
+}
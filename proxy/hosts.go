@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+)
+
+var ErrHostNotAllowed = errors.New("rewrite to host not allowed")
+
+// Private, loopback and link-local networks, for use in HostRewriter.DeniedHosts to guard against SSRF
+var PrivateNetworks = []string{
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"0.0.0.0/8",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+}
+
+// Returns whether the host (without port) matches a pattern
+//
+// Patterns are either CIDRs (e.g. 10.0.0.0/8), matched against IP hosts, or hostname globs (e.g. *.internal), matched case-insensitively
+func matchHost(host string, pattern string) bool {
+	if _, network, err := net.ParseCIDR(pattern); err == nil {
+		ip := net.ParseIP(host)
+		return ip != nil && network.Contains(ip)
+	}
+
+	// Exact IPs may be written differently (e.g. ::1 and 0:0:0:0:0:0:0:1)
+	if ip := net.ParseIP(pattern); ip != nil {
+		return ip.Equal(net.ParseIP(host))
+	}
+
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(host))
+
+	return err == nil && matched
+}
+
+func matchAnyHost(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matchHost(host, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns an error if rewriting to the host is not allowed by the allow and deny lists
+//
+// Hostnames are matched as is and are not resolved, so hostname targets should be restricted through AllowedHosts
+func checkHost(hostport string, allowed, denied []string) error {
+	if len(allowed) == 0 && len(denied) == 0 {
+		return nil
+	}
+
+	host := hostport
+
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+
+	if matchAnyHost(host, denied) {
+		return fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, host)
+	}
+
+	if len(allowed) > 0 && !matchAnyHost(host, allowed) {
+		return fmt.Errorf("%w: %s is not in the allowlist", ErrHostNotAllowed, host)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostRewriterHostLists(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		allowed []string
+		denied  []string
+		ok      bool
+	}{
+		{"no lists", "10.0.0.1", nil, nil, true},
+		{"allowed glob", "api.internal", []string{"*.internal"}, PrivateNetworks, true},
+		{"allowed glob with port", "api.internal:8080", []string{"*.internal"}, nil, true},
+		{"not in allowlist", "evil.example", []string{"*.internal"}, nil, false},
+		{"public ip", "93.184.216.34", nil, PrivateNetworks, true},
+		{"private ip", "10.1.2.3:80", nil, PrivateNetworks, false},
+		{"loopback", "127.0.0.1", nil, PrivateNetworks, false},
+		{"link-local metadata", "169.254.169.254", nil, PrivateNetworks, false},
+		{"ipv6 loopback", "[::1]:8080", nil, PrivateNetworks, false},
+		{"ipv6 link-local", "[fe80::1]", nil, PrivateNetworks, false},
+		{"deny wins over allow", "10.0.0.1", []string{"10.0.0.0/8"}, []string{"10.0.0.1"}, false},
+		{"trailing dot", "metadata.google.internal.", nil, []string{"metadata.google.internal"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingTransport{}
+
+			rt := NewHostRewriter(tt.host, next, (&testLogger{}).log)
+			rt.AllowedHosts = tt.allowed
+			rt.DeniedHosts = tt.denied
+
+			_, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://public.example/", nil))
+
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("permitted host rejected: %s", err)
+				}
+
+				if next.req == nil || next.req.URL.Host != tt.host {
+					t.Fatal("request not passed on to the permitted host")
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrHostNotAllowed) {
+				t.Fatalf("got %v, want ErrHostNotAllowed", err)
+			}
+
+			if next.req != nil {
+				t.Fatal("request made to a disallowed host")
+			}
+		})
+	}
+}
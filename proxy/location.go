@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var defaultLocationHeaders = []string{
+	"Location",
+	"Content-Location",
+}
+
+// LocationRewriter rewrites response headers (such as Location on redirects) that point at the upstream host
+// so they point at the public host instead
+//
+// Relative locations (e.g. /login) are left as is since clients resolve them against the public host. Locations
+// pointing at any other host are also left as is
+type LocationRewriter struct {
+	next   http.RoundTripper
+	logger Logger
+
+	// The public host to rewrite to, defaults to the Host of the incoming request (before any HostRewriter)
+	PublicHost string
+
+	// The upstream host to rewrite from, defaults to the host the request was finally sent to
+	UpstreamHost string
+
+	// Scheme to set on rewritten locations (e.g. https), if empty the scheme of the location is preserved
+	Scheme string
+
+	// Response headers to rewrite, defaults to Location and Content-Location
+	Headers []string
+}
+
+func NewLocationRewriter(next http.RoundTripper, logger Logger) LocationRewriter {
+	return LocationRewriter{
+		next:   next,
+		logger: logger,
+	}
+}
+
+func (rt LocationRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	// HostRewriter modifies the request in place, so the public host must be saved first
+	publicHost := rt.PublicHost
+
+	if publicHost == "" {
+		publicHost = req.Host
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+
+	if err != nil || publicHost == "" {
+		return resp, err
+	}
+
+	upstreamHost := rt.UpstreamHost
+
+	if upstreamHost == "" && resp.Request != nil {
+		upstreamHost = resp.Request.URL.Host
+	}
+
+	if upstreamHost == "" {
+		return resp, nil
+	}
+
+	headers := rt.Headers
+
+	if headers == nil {
+		headers = defaultLocationHeaders
+	}
+
+	for _, h := range headers {
+		values := resp.Header.Values(h)
+
+		if len(values) == 0 {
+			continue
+		}
+
+		rewritten := make([]string, len(values))
+
+		for i, v := range values {
+			rewritten[i] = rt.rewriteLocation(v, upstreamHost, publicHost)
+		}
+
+		resp.Header[http.CanonicalHeaderKey(h)] = rewritten
+	}
+
+	return resp, nil
+}
+
+func (rt LocationRewriter) rewriteLocation(location, upstreamHost, publicHost string) string {
+	u, err := url.Parse(location)
+
+	// Relative locations already resolve against the public host
+	if err != nil || u.Host == "" {
+		return location
+	}
+
+	if !strings.EqualFold(u.Host, upstreamHost) {
+		return location
+	}
+
+	u.Host = publicHost
+
+	if rt.Scheme != "" && u.Scheme != "" {
+		u.Scheme = rt.Scheme
+	}
+
+	if rt.logger != nil {
+		rt.logger("Rewriting location to " + u.String() + " from " + location)
+	}
+
+	return u.String()
+}
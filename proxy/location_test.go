@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Redirects every request with the given response headers
+type redirectTransport struct {
+	headers http.Header
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusFound, Header: rt.headers.Clone(), Body: http.NoBody, Request: req}, nil
+}
+
+func TestLocationRewriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		scheme   string
+		want     string
+	}{
+		{"absolute", "http://upstream.internal/login?next=%2Fme", "", "http://public.example/login?next=%2Fme"},
+		{"absolute with scheme", "http://upstream.internal/login", "https", "https://public.example/login"},
+		{"relative", "/login", "", "/login"},
+		{"relative without slash", "login", "", "login"},
+		{"other host", "https://auth.example/login", "", "https://auth.example/login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := redirectTransport{headers: http.Header{"Location": {tt.location}}}
+
+			rt := NewLocationRewriter(NewHostRewriter("upstream.internal", upstream, (&testLogger{}).log), nil)
+			rt.Scheme = tt.scheme
+
+			resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://public.example/account", nil))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := resp.Header.Get("Location"); got != tt.want {
+				t.Fatalf("got Location %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocationRewriterHeaders(t *testing.T) {
+	upstream := redirectTransport{headers: http.Header{
+		"Location":         {"http://upstream.internal/a"},
+		"Content-Location": {"http://upstream.internal/b"},
+		"Link":             {"http://upstream.internal/c"},
+	}}
+
+	logger := &testLogger{}
+
+	rt := NewLocationRewriter(upstream, logger.log)
+	rt.PublicHost = "public.example"
+	rt.UpstreamHost = "upstream.internal"
+	rt.Headers = []string{"Link"}
+
+	resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://upstream.internal/", nil))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the configured headers are rewritten
+	if got := resp.Header.Get("Link"); got != "http://public.example/c" {
+		t.Fatalf("got Link %s, want it rewritten", got)
+	}
+
+	if got := resp.Header.Get("Location"); got != "http://upstream.internal/a" {
+		t.Fatalf("got Location %s, want it left as is", got)
+	}
+
+	if len(logger.lines) != 1 {
+		t.Fatalf("got %d log lines, want 1: %q", len(logger.lines), logger.lines)
+	}
+}
@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+)
+
+// PathRewriter rewrites the path of a request before passing it on to the next round tripper
+type PathRewriter struct {
+	next   http.RoundTripper
+	logger Logger
+
+	// Prefix to strip from the request path
+	//
+	// Matched on whole path segments, so /api matches /api and /api/x but not /apiv2. Requests whose path does
+	// not start with this prefix are passed through unchanged
+	StripPrefix string
+
+	// Prefix to add to the request path, applied after StripPrefix
+	AddPrefix string
+}
+
+func NewPathRewriter(stripPrefix, addPrefix string, next http.RoundTripper, logger Logger) PathRewriter {
+	return PathRewriter{
+		next:        next,
+		logger:      logger,
+		StripPrefix: stripPrefix,
+		AddPrefix:   addPrefix,
+	}
+}
+
+func (rt PathRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.StripPrefix != "" && !hasPathPrefix(req.URL.Path, rt.StripPrefix) {
+		return rt.next.RoundTrip(req)
+	}
+
+	// Avoid modifying the callers request
+	newReq := req.Clone(req.Context())
+
+	newReq.URL.Path = rewritePath(req.URL.Path, rt.StripPrefix, rt.AddPrefix)
+
+	if req.URL.RawPath != "" {
+		if hasPathPrefix(req.URL.RawPath, rt.StripPrefix) {
+			newReq.URL.RawPath = rewritePath(req.URL.RawPath, rt.StripPrefix, rt.AddPrefix)
+		} else {
+			newReq.URL.RawPath = ""
+		}
+	}
+
+	if rt.logger != nil {
+		rt.logger("Rewriting path to " + newReq.URL.Path + " from " + req.URL.Path)
+	}
+
+	return rt.next.RoundTrip(newReq)
+}
+
+// Returns whether path starts with prefix on a segment boundary
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "" || strings.HasSuffix(prefix, "/") {
+		return strings.HasPrefix(path, prefix)
+	}
+
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func rewritePath(path, stripPrefix, addPrefix string) string {
+	path = strings.TrimPrefix(path, stripPrefix)
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	if addPrefix != "" {
+		path = strings.TrimSuffix(addPrefix, "/") + path
+	}
+
+	return path
+}
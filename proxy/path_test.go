@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathRewriter(t *testing.T) {
+	tests := []struct {
+		strip, add string
+		path, want string
+	}{
+		{"/api", "", "/api/users", "/users"},
+		{"/api", "", "/api", "/"},
+		{"/api", "/v1", "/api/users", "/v1/users"},
+		{"/api", "", "/apiv2/users", "/apiv2/users"},
+		{"/api", "/v1", "/apiv2", "/apiv2"},
+		{"/api/", "", "/api/users", "/users"},
+		{"/api/", "", "/apiv2/users", "/apiv2/users"},
+		{"", "/v1", "/users", "/v1/users"},
+	}
+
+	for _, tt := range tests {
+		next := &recordingTransport{}
+		rt := NewPathRewriter(tt.strip, tt.add, next, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "http://example.com"+tt.path, nil)
+
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := next.req.URL.Path; got != tt.want {
+			t.Errorf("strip %q add %q: %s rewritten to %s, want %s", tt.strip, tt.add, tt.path, got, tt.want)
+		}
+
+		if req.URL.Path != tt.path {
+			t.Errorf("callers request was modified")
+		}
+	}
+}
+
+func TestPathRewriterRawPath(t *testing.T) {
+	next := &recordingTransport{}
+	rt := NewPathRewriter("/api", "/v1", next, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/api/a%2Fb", nil)
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := next.req.URL.EscapedPath(); got != "/v1/a%2Fb" {
+		t.Fatalf("got %s, want /v1/a%%2Fb", got)
+	}
+}
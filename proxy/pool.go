@@ -0,0 +1,123 @@
+package proxy
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrNoHealthyHosts = errors.New("no healthy hosts in pool")
+
+// The strategy used by HostPoolRewriter to pick a host
+type PoolStrategy int
+
+const (
+	RoundRobin PoolStrategy = iota
+	Random
+)
+
+// HostPoolRewriter spreads requests across a pool of hosts, rewriting each request to a single host
+// in the same way as HostRewriter
+type HostPoolRewriter struct {
+	hosts    []string
+	strategy PoolStrategy
+	next     http.RoundTripper
+	logger   Logger
+	counter  uint64
+
+	mu   sync.RWMutex
+	down map[string]bool
+
+	// Scheme to force on the rewritten request, see HostRewriter.Scheme
+	Scheme string
+}
+
+func NewHostPoolRewriter(hosts []string, strategy PoolStrategy, next http.RoundTripper, logger Logger) *HostPoolRewriter {
+	return &HostPoolRewriter{
+		hosts:    hosts,
+		strategy: strategy,
+		next:     next,
+		logger:   logger,
+		down:     make(map[string]bool),
+	}
+}
+
+// MarkDown flags a host as unhealthy, unhealthy hosts are skipped until marked up again
+func (rt *HostPoolRewriter) MarkDown(host string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	rt.down[host] = true
+}
+
+// MarkUp flags a host as healthy again
+func (rt *HostPoolRewriter) MarkUp(host string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	delete(rt.down, host)
+}
+
+// Returns the hosts that are currently healthy
+func (rt *HostPoolRewriter) Healthy() []string {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	var healthy []string
+
+	for _, host := range rt.hosts {
+		if !rt.down[host] {
+			healthy = append(healthy, host)
+		}
+	}
+
+	return healthy
+}
+
+// Picks the host to use for the next request
+func (rt *HostPoolRewriter) pick() (string, error) {
+	switch rt.strategy {
+	case Random:
+		healthy := rt.Healthy()
+
+		if len(healthy) == 0 {
+			return "", ErrNoHealthyHosts
+		}
+
+		return healthy[rand.Intn(len(healthy))], nil
+	default:
+		if len(rt.hosts) == 0 {
+			return "", ErrNoHealthyHosts
+		}
+
+		start := atomic.AddUint64(&rt.counter, 1) - 1
+
+		rt.mu.RLock()
+		defer rt.mu.RUnlock()
+
+		for i := 0; i < len(rt.hosts); i++ {
+			host := rt.hosts[(start+uint64(i))%uint64(len(rt.hosts))]
+
+			if !rt.down[host] {
+				return host, nil
+			}
+		}
+
+		return "", ErrNoHealthyHosts
+	}
+}
+
+func (rt *HostPoolRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	host, err := rt.pick()
+
+	if err != nil {
+		return nil, err
+	}
+
+	hr := NewHostRewriter(host, rt.next, rt.logger)
+	hr.Scheme = rt.Scheme
+
+	return hr.RoundTrip(req)
+}
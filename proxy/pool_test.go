@@ -0,0 +1,59 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostPoolRewriterRoundRobin(t *testing.T) {
+	next := &recordingTransport{}
+	hosts := []string{"a.internal", "b.internal", "c.internal"}
+
+	rt := NewHostPoolRewriter(hosts, RoundRobin, next, (&testLogger{}).log)
+
+	counts := map[string]int{}
+
+	for i := 0; i < 9; i++ {
+		if _, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://public.example/", nil)); err != nil {
+			t.Fatal(err)
+		}
+
+		if want := hosts[i%len(hosts)]; next.req.URL.Host != want {
+			t.Fatalf("request %d went to %s, want %s", i, next.req.URL.Host, want)
+		}
+
+		counts[next.req.URL.Host]++
+	}
+
+	for _, host := range hosts {
+		if counts[host] != 3 {
+			t.Fatalf("%s got %d requests, want 3", host, counts[host])
+		}
+	}
+}
+
+func TestHostPoolRewriterSkipsDownHost(t *testing.T) {
+	next := &recordingTransport{}
+
+	rt := NewHostPoolRewriter([]string{"a.internal", "b.internal", "c.internal"}, RoundRobin, next, (&testLogger{}).log)
+	rt.MarkDown("b.internal")
+
+	for i := 0; i < 6; i++ {
+		if _, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://public.example/", nil)); err != nil {
+			t.Fatal(err)
+		}
+
+		if next.req.URL.Host == "b.internal" {
+			t.Fatalf("request %d went to a host marked down", i)
+		}
+	}
+
+	rt.MarkDown("a.internal")
+	rt.MarkDown("c.internal")
+
+	if _, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://public.example/", nil)); !errors.Is(err, ErrNoHealthyHosts) {
+		t.Fatalf("got %v, want ErrNoHealthyHosts", err)
+	}
+}
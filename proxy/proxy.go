@@ -1,17 +1,92 @@
 package proxy
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
+var ErrUpstreamTimeout = errors.New("upstream timed out")
+
 type Logger func(s string)
 
 type HostRewriter struct {
 	host   string
 	next   http.RoundTripper
 	logger Logger
+
+	// Scheme to force on the rewritten request (e.g. http or https)
+	//
+	// If empty, the scheme of the incoming request is preserved
+	Scheme string
+
+	// Maximum time to wait for the upstream, covering reading the response body. If zero, there is no timeout
+	//
+	// When exceeded, the round trip fails with ErrUpstreamTimeout
+	Timeout time.Duration
+
+	// Removes the entire query string from log lines
+	RedactQuery bool
+
+	// Query keys whose values are replaced with REDACTED in log lines, matched case-insensitively
+	//
+	// The outgoing request is not affected
+	RedactQueryKeys []string
+
+	// If set, the host being rewritten to must match one of these patterns, see DeniedHosts for the pattern format
+	AllowedHosts []string
+
+	// The host being rewritten to must not match any of these patterns, checked before AllowedHosts
+	//
+	// Patterns are CIDRs (e.g. 10.0.0.0/8, see PrivateNetworks), IPs or hostname globs (e.g. *.internal). Disallowed
+	// rewrites fail with ErrHostNotAllowed before any request is made
+	DeniedHosts []string
+}
+
+// Returns the URL as it should appear in log lines
+func (rt HostRewriter) redactURL(u *url.URL) string {
+	if u.RawQuery == "" || (!rt.RedactQuery && len(rt.RedactQueryKeys) == 0) {
+		return u.String()
+	}
+
+	redacted := *u
+
+	if rt.RedactQuery {
+		redacted.RawQuery = ""
+		return redacted.String()
+	}
+
+	query := u.Query()
+
+	for key := range query {
+		for _, rk := range rt.RedactQueryKeys {
+			if strings.EqualFold(key, rk) {
+				query[key] = []string{"REDACTED"}
+				break
+			}
+		}
+	}
+
+	redacted.RawQuery = query.Encode()
+
+	return redacted.String()
+}
+
+// Cancels the request context once the response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 func NewHostRewriter(host string, next http.RoundTripper, logger Logger) HostRewriter {
@@ -23,15 +98,58 @@ func NewHostRewriter(host string, next http.RoundTripper, logger Logger) HostRew
 }
 
 func (rt HostRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := checkHost(rt.host, rt.AllowedHosts, rt.DeniedHosts); err != nil {
+		rt.logger("Refusing to rewrite host: " + err.Error())
+		return nil, err
+	}
+
 	urlStr := strings.Replace(req.URL.String(), req.Host, rt.host, 1)
-	req.URL, _ = url.Parse(urlStr)
+	newURL, err := url.Parse(urlStr)
+
+	if err != nil {
+		if rt.RedactQuery || len(rt.RedactQueryKeys) > 0 {
+			// The URL can't be parsed to redact individual keys
+			urlStr = "<redacted>"
+		}
 
-	logStr := "Rewriting host to " + rt.host + " from " + req.Host + " [" + req.URL.String() + "]"
+		rt.logger("Failed to parse rewritten URL " + urlStr + ": " + err.Error())
+		return nil, err
+	}
+
+	req.URL = newURL
+
+	logStr := "Rewriting host to " + rt.host + " from " + req.Host + " [" + rt.redactURL(req.URL) + "]"
 
 	rt.logger(logStr)
 
 	req.Host = rt.host
-	req.URL.Scheme = "http"
 
-	return rt.next.RoundTrip(req)
+	if rt.Scheme != "" {
+		req.URL.Scheme = rt.Scheme
+	} else if req.URL.Scheme == "" {
+		req.URL.Scheme = "http"
+	}
+
+	if rt.Timeout <= 0 {
+		return rt.next.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), rt.Timeout)
+
+	resp, err := rt.next.RoundTrip(req.WithContext(ctx))
+
+	if err != nil {
+		cancel()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			rt.logger("Upstream " + rt.host + " timed out after " + rt.Timeout.String())
+			return nil, fmt.Errorf("%w: %s after %s", ErrUpstreamTimeout, rt.host, rt.Timeout)
+		}
+
+		return nil, err
+	}
+
+	resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
 }
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Records the last request it was given
+type recordingTransport struct {
+	req *http.Request
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.req = req
+
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+// Collects log lines
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) log(s string) {
+	l.lines = append(l.lines, s)
+}
+
+func TestHostRewriterScheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		scheme string
+		want   string
+	}{
+		{"https preserved", "https://public.example/users", "", "https://upstream.internal/users"},
+		{"http preserved", "http://public.example/users", "", "http://upstream.internal/users"},
+		{"override to https", "http://public.example/users", "https", "https://upstream.internal/users"},
+		{"override to http", "https://public.example/users", "http", "http://upstream.internal/users"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingTransport{}
+			logger := &testLogger{}
+
+			rt := NewHostRewriter("upstream.internal", next, logger.log)
+			rt.Scheme = tt.scheme
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+
+			if _, err := rt.RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := next.req.URL.String(); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+
+			if next.req.Host != "upstream.internal" {
+				t.Fatalf("got host %s, want upstream.internal", next.req.Host)
+			}
+		})
+	}
+}
+
+func TestHostRewriterSchemeDefault(t *testing.T) {
+	next := &recordingTransport{}
+	logger := &testLogger{}
+
+	rt := NewHostRewriter("upstream.internal", next, logger.log)
+
+	req := httptest.NewRequest(http.MethodGet, "http://public.example/users", nil)
+	req.URL.Scheme = ""
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := next.req.URL.String(); got != "http://upstream.internal/users" {
+		t.Fatalf("got %s, want http://upstream.internal/users", got)
+	}
+}
+
+func TestHostRewriterUnparsableURL(t *testing.T) {
+	next := &recordingTransport{}
+	logger := &testLogger{}
+
+	rt := NewHostRewriter("bad host%zz", next, logger.log)
+
+	req := httptest.NewRequest(http.MethodGet, "http://public.example/users", nil)
+
+	resp, err := rt.RoundTrip(req)
+
+	if err == nil || resp != nil {
+		t.Fatalf("got %v, %v, want an error", resp, err)
+	}
+
+	if next.req != nil {
+		t.Fatal("request was passed on despite the unparsable URL")
+	}
+
+	if len(logger.lines) != 1 || !strings.HasPrefix(logger.lines[0], "Failed to parse rewritten URL") {
+		t.Fatalf("unexpected log lines %q", logger.lines)
+	}
+}
+
+func TestHostRewriterRedaction(t *testing.T) {
+	const target = "http://public.example/callback?code=abc&Token=s3cr3t&page=2"
+
+	tests := []struct {
+		name   string
+		setup  func(rt *HostRewriter)
+		logged string
+	}{
+		{"default", func(rt *HostRewriter) {}, "[http://upstream.internal/callback?code=abc&Token=s3cr3t&page=2]"},
+		{"query keys", func(rt *HostRewriter) { rt.RedactQueryKeys = []string{"token", "code"} }, "[http://upstream.internal/callback?Token=REDACTED&code=REDACTED&page=2]"},
+		{"whole query", func(rt *HostRewriter) { rt.RedactQuery = true }, "[http://upstream.internal/callback]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &recordingTransport{}
+			logger := &testLogger{}
+
+			rt := NewHostRewriter("upstream.internal", next, logger.log)
+			tt.setup(&rt)
+
+			if _, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, target, nil)); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(logger.lines) != 1 || !strings.HasSuffix(logger.lines[0], tt.logged) {
+				t.Fatalf("got log lines %q, want one ending in %s", logger.lines, tt.logged)
+			}
+
+			// Redaction only applies to logs
+			if got := next.req.URL.Query().Get("Token"); got != "s3cr3t" {
+				t.Fatalf("outgoing request has Token %q, want s3cr3t", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBaseDelay = 100 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+)
+
+var defaultRetryStatuses = []int{
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryTransport retries idempotent requests on connection errors and retriable status codes with exponential backoff
+//
+// Requests are considered idempotent if their method is idempotent (as per RFC 7231) or they
+// have an Idempotency-Key header set
+type RetryTransport struct {
+	next   http.RoundTripper
+	logger Logger
+
+	// Maximum number of attempts (including the first), defaults to 3
+	MaxAttempts int
+
+	// Delay before the first retry, doubled for every subsequent retry. Defaults to 100ms
+	BaseDelay time.Duration
+
+	// Maximum delay between retries, defaults to 5s
+	MaxDelay time.Duration
+
+	// Status codes that should be retried, defaults to 502, 503 and 504
+	RetryStatuses []int
+}
+
+func NewRetryTransport(next http.RoundTripper, logger Logger) RetryTransport {
+	return RetryTransport{
+		next:   next,
+		logger: logger,
+	}
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+func (rt RetryTransport) backoff(attempt int) time.Duration {
+	baseDelay := rt.BaseDelay
+
+	if baseDelay == 0 {
+		baseDelay = defaultRetryBaseDelay
+	}
+
+	maxDelay := rt.MaxDelay
+
+	if maxDelay == 0 {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	delay := baseDelay
+
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+
+		if delay >= maxDelay || delay <= 0 {
+			return maxDelay
+		}
+	}
+
+	if delay > maxDelay {
+		return maxDelay
+	}
+
+	return delay
+}
+
+func (rt RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req) {
+		return rt.next.RoundTrip(req)
+	}
+
+	maxAttempts := rt.MaxAttempts
+
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryAttempts
+	}
+
+	retryStatuses := rt.RetryStatuses
+
+	if retryStatuses == nil {
+		retryStatuses = defaultRetryStatuses
+	}
+
+	// Buffer the body so it can be resent on retries
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.Body, _ = req.GetBody()
+	}
+
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+
+			if err != nil {
+				return nil, err
+			}
+
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err := rt.next.RoundTrip(attemptReq)
+
+		if err == nil && !slices.Contains(retryStatuses, resp.StatusCode) {
+			return resp, nil
+		}
+
+		if attempt >= maxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		if rt.logger != nil {
+			if err != nil {
+				rt.logger("Retrying " + req.Method + " " + req.URL.String() + " after error (attempt " + strconv.Itoa(attempt) + "): " + err.Error())
+			} else {
+				rt.logger("Retrying " + req.Method + " " + req.URL.String() + " after status " + strconv.Itoa(resp.StatusCode) + " (attempt " + strconv.Itoa(attempt) + ")")
+			}
+		}
+
+		if resp != nil {
+			// Drain the body so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(rt.backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Responds with the given status codes in order, repeating the last one once exhausted
+type statusTransport struct {
+	statuses []int
+	calls    int
+}
+
+func (rt *statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := rt.statuses[len(rt.statuses)-1]
+
+	if rt.calls < len(rt.statuses) {
+		status = rt.statuses[rt.calls]
+	}
+
+	rt.calls++
+
+	return &http.Response{StatusCode: status, Body: http.NoBody, Request: req}, nil
+}
+
+func TestRetryTransportSuccessAfterFailures(t *testing.T) {
+	next := &statusTransport{statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK}}
+	logger := &testLogger{}
+
+	rt := NewRetryTransport(next, logger.log)
+	rt.BaseDelay = time.Millisecond
+
+	resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://upstream.internal/", nil))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %d, want 200", resp.StatusCode)
+	}
+
+	if next.calls != 3 {
+		t.Fatalf("got %d attempts, want 3", next.calls)
+	}
+
+	if len(logger.lines) != 2 {
+		t.Fatalf("got %d retry log lines, want 2: %v", len(logger.lines), logger.lines)
+	}
+}
+
+func TestRetryTransportGivesUp(t *testing.T) {
+	next := &statusTransport{statuses: []int{http.StatusServiceUnavailable}}
+
+	rt := NewRetryTransport(next, nil)
+	rt.MaxAttempts = 4
+	rt.BaseDelay = time.Millisecond
+
+	resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://upstream.internal/", nil))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got status %d, want the last 503", resp.StatusCode)
+	}
+
+	if next.calls != 4 {
+		t.Fatalf("got %d attempts, want 4", next.calls)
+	}
+}
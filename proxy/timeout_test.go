@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Returns a HostRewriter to an upstream responding after delay
+func newDelayedUpstream(t *testing.T, delay time.Duration) HostRewriter {
+	t.Helper()
+
+	release := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-release:
+		}
+
+		w.Write([]byte("ok"))
+	}))
+
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+
+	u, err := url.Parse(srv.URL)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rt := NewHostRewriter(u.Host, srv.Client().Transport, (&testLogger{}).log)
+	rt.Timeout = 50 * time.Millisecond
+
+	return rt
+}
+
+func TestHostRewriterTimeout(t *testing.T) {
+	rt := newDelayedUpstream(t, time.Minute)
+
+	start := time.Now()
+	resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://public.example/slow", nil))
+
+	if !errors.Is(err, ErrUpstreamTimeout) {
+		t.Fatalf("got %v, %v, want ErrUpstreamTimeout", resp, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("timeout fired after %s", elapsed)
+	}
+}
+
+func TestHostRewriterWithinTimeout(t *testing.T) {
+	rt := newDelayedUpstream(t, 0)
+
+	resp, err := rt.RoundTrip(httptest.NewRequest(http.MethodGet, "http://public.example/fast", nil))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	// The deadline must not be cancelled before the body is read
+	body, err := io.ReadAll(resp.Body)
+
+	if err != nil || string(body) != "ok" {
+		t.Fatalf("got body %q, %v", body, err)
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ConcurrencyLimit caps the number of requests being processed at the same time per identifier
+//
+// Every successful Acquire must be paired with a Release once the request is done
+type ConcurrencyLimit struct {
+	// Bucket is the bucket to use for the limit
+	Bucket string
+	// MaxConcurrent is the maximum number of requests that can be in flight at once
+	MaxConcurrent int
+	// SafetyTTL is how long the in-flight count lives after the first acquire, recovering from
+	// holders that crashed before releasing. Should be longer than the slowest request, defaults to 1 minute
+	SafetyTTL time.Duration
+	// Identifier is the identifier of the limit, otherwise DefaultIdentifier is used
+	Identifier func(r *http.Request) string
+}
+
+func (c ConcurrencyLimit) key(r *http.Request) string {
+	return c.Bucket + "-concurrency-" + hashIdentifier(c.Identifier, r)
+}
+
+// Takes a slot if there is one free, creating the count with the safety TTL if it does not exist
+//
+// KEYS[1] = count key, ARGV[1] = max concurrent, ARGV[2] = safety TTL (ms)
+//
+// Returns 1 if a slot was taken, 0 otherwise
+var concurrencyAcquireScript = redis.NewScript(`
+redis.call("SET", KEYS[1], 0, "PX", ARGV[2], "NX")
+
+if redis.call("INCR", KEYS[1]) > tonumber(ARGV[1]) then
+	redis.call("DECR", KEYS[1])
+	return 0
+end
+
+return 1
+`)
+
+// Frees a slot, never taking the count below zero or recreating an expired count
+//
+// KEYS[1] = count key
+var concurrencyReleaseScript = redis.NewScript(`
+local inFlight = tonumber(redis.call("GET", KEYS[1]) or "0")
+
+if inFlight > 0 then
+	redis.call("DECR", KEYS[1])
+end
+
+return 0
+`)
+
+// Acquire tries to take a slot for the request, returning false if the limit has been reached
+//
+// The count is updated atomically, so RLState.Redis must be set
+func (c ConcurrencyLimit) Acquire(ctx context.Context, r *http.Request) (bool, error) {
+	if State.Redis == nil {
+		return false, errors.New("concurrency limits require RLState.Redis to be set")
+	}
+
+	if c.SafetyTTL == 0 {
+		c.SafetyTTL = time.Minute
+	}
+
+	taken, err := concurrencyAcquireScript.Run(ctx, State.Redis, []string{c.key(r)}, c.MaxConcurrent, c.SafetyTTL.Milliseconds()).Int()
+
+	if err != nil {
+		return false, err
+	}
+
+	return taken == 1, nil
+}
+
+// Release frees the slot taken by a successful Acquire
+func (c ConcurrencyLimit) Release(ctx context.Context, r *http.Request) error {
+	if State.Redis == nil {
+		return errors.New("concurrency limits require RLState.Redis to be set")
+	}
+
+	return concurrencyReleaseScript.Run(ctx, State.Redis, []string{c.key(r)}).Err()
+}
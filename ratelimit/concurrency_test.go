@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	mr := setupTestState(t)
+
+	c := ConcurrencyLimit{Bucket: "uploads", MaxConcurrent: 3, SafetyTTL: time.Minute}
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		ok, err := c.Acquire(ctx, r)
+
+		if err != nil || !ok {
+			t.Fatalf("acquire %d: got %v, %v", i+1, ok, err)
+		}
+	}
+
+	// The (N+1)th acquire is rejected and does not take a slot
+	ok, err := c.Acquire(ctx, r)
+
+	if err != nil || ok {
+		t.Fatalf("acquire 4: got %v, %v, want false", ok, err)
+	}
+
+	if got, _ := mr.Get(c.key(r)); got != "3" {
+		t.Fatalf("in flight count is %s after a rejected acquire, want 3", got)
+	}
+
+	if ttl := mr.TTL(c.key(r)); ttl <= 0 {
+		t.Fatalf("count has no safety TTL")
+	}
+
+	// Other identifiers have their own slots
+	if ok, err := c.Acquire(ctx, testRequest("10.0.0.2:1234")); err != nil || !ok {
+		t.Fatalf("acquire for another identifier: got %v, %v", ok, err)
+	}
+
+	if err := c.Release(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := c.Acquire(ctx, r); err != nil || !ok {
+		t.Fatalf("acquire after release: got %v, %v", ok, err)
+	}
+}
+
+func TestConcurrencyLimitReleaseNeverNegative(t *testing.T) {
+	mr := setupTestState(t)
+
+	c := ConcurrencyLimit{Bucket: "uploads", MaxConcurrent: 1, SafetyTTL: time.Minute}
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	if ok, err := c.Acquire(ctx, r); err != nil || !ok {
+		t.Fatalf("acquire: got %v, %v", ok, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := c.Release(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, _ := mr.Get(c.key(r)); got != "0" {
+		t.Fatalf("in flight count is %s after extra releases, want 0", got)
+	}
+
+	// Releasing an expired count does not recreate it without a TTL
+	mr.FastForward(2 * time.Minute)
+
+	if err := c.Release(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if mr.Exists(c.key(r)) {
+		t.Fatal("release recreated an expired count")
+	}
+
+	// Only one slot
+	if ok, _ := c.Acquire(ctx, r); !ok {
+		t.Fatal("acquire after expiry failed")
+	}
+
+	if ok, _ := c.Acquire(ctx, r); ok {
+		t.Fatal("second acquire succeeded with MaxConcurrent 1")
+	}
+}
+
+func TestConcurrencyLimitParallelAcquires(t *testing.T) {
+	setupTestState(t)
+
+	c := ConcurrencyLimit{Bucket: "uploads", MaxConcurrent: 5}
+	r := testRequest("10.0.0.1:1234")
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	acquired := 0
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			ok, err := c.Acquire(context.Background(), r)
+
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if ok {
+				mu.Lock()
+				acquired++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if acquired != 5 {
+		t.Fatalf("%d acquires succeeded, want 5", acquired)
+	}
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Drains the bucket by one request per leak interval then adds the request if there is room
+//
+// KEYS[1] = bucket key, ARGV[1] = capacity, ARGV[2] = leak interval (ms), ARGV[3] = now (ms)
+//
+// Returns {exceeded, level, ms until the next request leaks}
+var leakyBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local level = tonumber(redis.call("HGET", KEYS[1], "level") or "0")
+local ts = tonumber(redis.call("HGET", KEYS[1], "ts") or ARGV[3])
+
+local leaked = math.floor((now - ts) / interval)
+
+if leaked > 0 then
+	level = math.max(0, level - leaked)
+	ts = ts + leaked * interval
+end
+
+if level == 0 then
+	ts = now
+end
+
+local exceeded = 0
+
+if level < capacity then
+	level = level + 1
+else
+	exceeded = 1
+end
+
+redis.call("HSET", KEYS[1], "level", level, "ts", ts)
+redis.call("PEXPIRE", KEYS[1], level * interval)
+
+return {exceeded, level, interval - (now - ts)}
+`)
+
+func (rl Ratelimit) leakyBucketLimit(ctx context.Context, identifier string) (Limit, error) {
+	if State.Redis == nil {
+		return Limit{GotIdentifier: identifier}, errors.New("leaky bucket ratelimits require RLState.Redis to be set")
+	}
+
+	if rl.LeakInterval <= 0 {
+		return Limit{GotIdentifier: identifier}, errors.New("leaky bucket ratelimits require a positive LeakInterval")
+	}
+
+	res, err := leakyBucketScript.Run(
+		ctx,
+		State.Redis,
+		[]string{rl.Bucket + "-leaky-" + identifier},
+		rl.MaxRequests,
+		rl.LeakInterval.Milliseconds(),
+		time.Now().UnixMilli(),
+	).Int64Slice()
+
+	if err != nil {
+		return Limit{GotIdentifier: identifier}, err
+	}
+
+	if len(res) != 3 {
+		return Limit{GotIdentifier: identifier}, errors.New("unexpected leaky bucket script result of length " + strconv.Itoa(len(res)))
+	}
+
+	level := int(res[1])
+
+	return Limit{
+		GotIdentifier: identifier,
+		Exceeded:      res[0] == 1,
+		Made:          level,
+		Remaining:     rl.MaxRequests - level,
+		TimeToReset:   time.Duration(res[2]) * time.Millisecond,
+		MaxRequests:   rl.MaxRequests,
+		Bucket:        rl.Bucket,
+	}, nil
+}
+
+// Removes a request from the bucket without going below zero
+var leakyBucketRefundScript = redis.NewScript(`
+local level = tonumber(redis.call("HGET", KEYS[1], "level") or "0")
+
+if level > 0 then
+	redis.call("HSET", KEYS[1], "level", level - 1)
+end
+
+return level
+`)
+
+func (rl Ratelimit) leakyBucketRefund(ctx context.Context, identifier string) error {
+	if State.Redis == nil {
+		return errors.New("leaky bucket ratelimits require RLState.Redis to be set")
+	}
+
+	return leakyBucketRefundScript.Run(ctx, State.Redis, []string{rl.Bucket + "-leaky-" + identifier}).Err()
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLeakyBucketSteadyRate(t *testing.T) {
+	setupTestState(t)
+
+	rl := Ratelimit{Bucket: "leaky", MaxRequests: 1, LeakInterval: 20 * time.Millisecond, Strategy: LeakyBucket}
+	r := testRequest("10.0.0.1:1234")
+
+	// One request per leak interval never fills the bucket
+	for i := 0; i < 5; i++ {
+		limit, err := rl.Limit(context.Background(), r)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if limit.Exceeded {
+			t.Fatalf("request %d at the leak rate was rejected", i)
+		}
+
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+func TestLeakyBucketBurst(t *testing.T) {
+	setupTestState(t)
+
+	rl := Ratelimit{Bucket: "leaky", MaxRequests: 3, LeakInterval: time.Minute, Strategy: LeakyBucket}
+	r := testRequest("10.0.0.1:1234")
+
+	for i := 1; i <= 3; i++ {
+		limit, err := rl.Limit(context.Background(), r)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if limit.Exceeded || limit.Made != i || limit.Remaining != 3-i {
+			t.Fatalf("request %d: unexpected limit %+v", i, limit)
+		}
+	}
+
+	limit, err := rl.Limit(context.Background(), r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !limit.Exceeded {
+		t.Fatal("request to a full bucket was accepted")
+	}
+
+	if limit.TimeToReset <= 0 || limit.TimeToReset > time.Minute {
+		t.Fatalf("got TimeToReset %s, want the time until the next leak", limit.TimeToReset)
+	}
+
+	// Other identifiers have their own bucket
+	if limit, err := rl.Limit(context.Background(), testRequest("10.0.0.2:1234")); err != nil || limit.Exceeded {
+		t.Fatalf("separate identifier was limited: %+v %v", limit, err)
+	}
+}
+
+func TestLeakyBucketRequiresRedis(t *testing.T) {
+	setupTestState(t)
+	State.Redis = nil
+
+	rl := Ratelimit{Bucket: "leaky", MaxRequests: 1, LeakInterval: time.Second, Strategy: LeakyBucket}
+
+	if _, err := rl.Limit(context.Background(), testRequest("10.0.0.1:1234")); err == nil {
+		t.Fatal("expected an error without RLState.Redis")
+	}
+}
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/topicbotlist/eureka-port/hotcache"
+)
+
+// Penalty blocks identifiers that keep exceeding a ratelimit
+//
+// Once an identifier exceeds the ratelimit Threshold times within Window, it is blocked for BaseBlock.
+// Each subsequent block doubles in length up to MaxBlock. The escalation is forgotten once an
+// identifier goes Window past the end of its last block without being blocked again
+type Penalty struct {
+	// Threshold is the number of violations within Window that trigger a block
+	Threshold int
+	// Window is the interval violations are counted over
+	Window time.Duration
+	// BaseBlock is the length of the first block
+	BaseBlock time.Duration
+	// MaxBlock is the maximum length of a block, if zero, blocks are not capped
+	MaxBlock time.Duration
+}
+
+func (p Penalty) key(rl Ratelimit, kind, identifier string) string {
+	return rl.Bucket + "-" + kind + "-" + identifier
+}
+
+// Returns an exceeded Limit if the identifier is currently blocked
+func (p Penalty) blocked(ctx context.Context, rl Ratelimit, identifier string) (Limit, error) {
+	exists, err := State.HotCache.Exists(ctx, p.key(rl, "block", identifier))
+
+	if err != nil || !exists {
+		return Limit{GotIdentifier: identifier}, err
+	}
+
+	ttl, err := State.HotCache.Expiry(ctx, p.key(rl, "block", identifier))
+
+	if err != nil {
+		return Limit{GotIdentifier: identifier}, err
+	}
+
+	return Limit{
+		GotIdentifier: identifier,
+		Exceeded:      true,
+		Made:          rl.MaxRequests,
+		TimeToReset:   ttl,
+		MaxRequests:   rl.MaxRequests,
+		Bucket:        rl.Bucket,
+	}, nil
+}
+
+// Increments a counter, creating it with the given expiry if it does not exist, and returns the new value
+func incrementCounter(ctx context.Context, key string, expiry time.Duration) (int, error) {
+	exists, err := State.HotCache.Exists(ctx, key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		err = State.HotCache.Set(ctx, key, &zero, expiry)
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = State.HotCache.IncrementOne(ctx, key)
+
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := State.HotCache.Get(ctx, key)
+
+	if errors.Is(err, hotcache.ErrHotCacheDataNotFound) {
+		return 1, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return *v, nil
+}
+
+// Records a violation, blocking the identifier if the threshold has been reached
+func (p Penalty) violation(ctx context.Context, rl Ratelimit, limit Limit) (Limit, error) {
+	violations, err := incrementCounter(ctx, p.key(rl, "violations", limit.GotIdentifier), p.Window)
+
+	if err != nil {
+		return limit, err
+	}
+
+	if violations < p.Threshold {
+		return limit, nil
+	}
+
+	strikes := 0
+
+	s, err := State.HotCache.Get(ctx, p.key(rl, "strikes", limit.GotIdentifier))
+
+	if err == nil {
+		strikes = *s
+	} else if !errors.Is(err, hotcache.ErrHotCacheDataNotFound) {
+		return limit, err
+	}
+
+	block := p.BaseBlock
+
+	for i := 0; i < strikes; i++ {
+		block *= 2
+
+		if p.MaxBlock > 0 && block >= p.MaxBlock {
+			break
+		}
+	}
+
+	if p.MaxBlock > 0 && block > p.MaxBlock {
+		block = p.MaxBlock
+	}
+
+	strikes++
+
+	err = State.HotCache.Set(ctx, p.key(rl, "block", limit.GotIdentifier), &strikes, block)
+
+	if err != nil {
+		return limit, err
+	}
+
+	err = State.HotCache.Set(ctx, p.key(rl, "strikes", limit.GotIdentifier), &strikes, block+p.Window)
+
+	if err != nil {
+		return limit, err
+	}
+
+	err = State.HotCache.Delete(ctx, p.key(rl, "violations", limit.GotIdentifier))
+
+	if err != nil {
+		return limit, err
+	}
+
+	limit.TimeToReset = block
+
+	return limit, nil
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPenaltyEscalates(t *testing.T) {
+	mr := setupTestState(t)
+
+	rl := Ratelimit{
+		Bucket:      "penalty",
+		MaxRequests: 1,
+		Expiry:      time.Hour,
+		Penalty: &Penalty{
+			Threshold: 2,
+			Window:    time.Minute,
+			BaseBlock: 10 * time.Second,
+			MaxBlock:  30 * time.Second,
+		},
+	}
+
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	limit := func() Limit {
+		t.Helper()
+
+		l, err := rl.Limit(ctx, r)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return l
+	}
+
+	// Use up the ratelimit
+	for !limit().Exceeded {
+	}
+
+	for _, want := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second} {
+		// The first violation was the request that exceeded the ratelimit, the second triggers the block
+		if l := limit(); l.TimeToReset != want {
+			t.Fatalf("got block of %s, want %s", l.TimeToReset, want)
+		}
+
+		// Blocked requests report the block and don't count as violations
+		if l := limit(); !l.Exceeded || l.TimeToReset != want {
+			t.Fatalf("blocked request got %+v, want a block of %s", l, want)
+		}
+
+		mr.FastForward(want + time.Second)
+
+		if l := limit(); !l.Exceeded {
+			t.Fatalf("request after the block got %+v, want the ratelimit to still be exceeded", l)
+		}
+	}
+}
+
+func TestPenaltyForgotten(t *testing.T) {
+	mr := setupTestState(t)
+
+	rl := Ratelimit{
+		Bucket:      "penalty",
+		MaxRequests: 0,
+		Expiry:      time.Hour,
+		Penalty:     &Penalty{Threshold: 1, Window: time.Minute, BaseBlock: 10 * time.Second},
+	}
+
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	limit := func() Limit {
+		t.Helper()
+
+		l, err := rl.Limit(ctx, r)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return l
+	}
+
+	limit()
+
+	if l := limit(); l.TimeToReset != 10*time.Second {
+		t.Fatalf("got block of %s, want 10s", l.TimeToReset)
+	}
+
+	mr.FastForward(11 * time.Second)
+
+	if l := limit(); l.TimeToReset != 20*time.Second {
+		t.Fatalf("got block of %s, want 20s", l.TimeToReset)
+	}
+
+	// A window past the end of the last block, the escalation is forgotten
+	mr.FastForward(20*time.Second + time.Minute + time.Second)
+
+	if l := limit(); l.TimeToReset != 10*time.Second {
+		t.Fatalf("got block of %s after the escalation expired, want 10s", l.TimeToReset)
+	}
+}
@@ -4,12 +4,13 @@ package ratelimit
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
-	"errors"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/topicbotlist/eureka-port/hotcache"
 )
 
@@ -17,10 +18,39 @@ var zero = 0
 
 type RLState struct {
 	HotCache hotcache.HotCache[int]
+
+	// Redis is used by strategies that need atomic scripting (e.g. LeakyBucket), optional otherwise
+	Redis redis.Scripter
+
+	// HotCachePrefix is the key prefix HotCache uses in Redis, needed to refund FixedWindow ratelimits
+	HotCachePrefix string
 }
 
 var State *RLState
 
+// Strategy is the algorithm used to enforce a ratelimit
+type Strategy int
+
+const (
+	// FixedWindow allows MaxRequests requests per Expiry, resetting at the end of each window
+	FixedWindow Strategy = iota
+	// LeakyBucket queues up to MaxRequests requests, draining one every LeakInterval
+	//
+	// This smooths out bursts to a constant rate and requires RLState.Redis to be set
+	LeakyBucket
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case FixedWindow:
+		return "fixed_window"
+	case LeakyBucket:
+		return "leaky_bucket"
+	default:
+		return "unknown"
+	}
+}
+
 func SetupState(s *RLState) {
 	State = s
 }
@@ -34,6 +64,16 @@ type Ratelimit struct {
 	Bucket string
 	// Identifier is the identifier of the ratelimit, otherwise DefaultIdentifier is used
 	Identifier func(r *http.Request) string
+	// Strategy is the algorithm used for the ratelimit, defaults to FixedWindow
+	//
+	// With LeakyBucket, MaxRequests is the bucket size and Expiry is unused
+	Strategy Strategy
+	// LeakInterval is how often one request drains from the bucket, only used by LeakyBucket
+	LeakInterval time.Duration
+	// Penalty, if set, temporarily blocks identifiers that repeatedly exceed the ratelimit
+	Penalty *Penalty
+	// OnExceeded, if set, builds the response returned by Response when the ratelimit is exceeded
+	OnExceeded func(l Limit) Response
 }
 
 // Limit is used to check if the ratelimit has been exceeded
@@ -45,6 +85,8 @@ type Limit struct {
 	// Remaining is the number of requests remaining in the ratelimit
 	Remaining int
 	// TimeToReset is the time remaining until the ratelimit resets
+	//
+	// For LeakyBucket, this is the time until the next slot frees up
 	TimeToReset time.Duration
 	// GotIdentifier is the identifier of the ratelimit
 	GotIdentifier string
@@ -54,6 +96,26 @@ type Limit struct {
 	Bucket string
 }
 
+// HeadersAt is like Headers but emits Retry-After as an HTTP-date relative to now
+//
+// Useful for clients that only understand the absolute form of Retry-After
+func (l Limit) HeadersAt(now time.Time) map[string]string {
+	headers := l.Headers()
+
+	if l.Exceeded {
+		// HTTP-dates only have second precision, round up so clients never retry early
+		retryAt := now.Add(l.TimeToReset)
+
+		if t := retryAt.Truncate(time.Second); !t.Equal(retryAt) {
+			retryAt = t.Add(time.Second)
+		}
+
+		headers["Retry-After"] = retryAt.UTC().Format(http.TimeFormat)
+	}
+
+	return headers
+}
+
 func (l Limit) Headers() map[string]string {
 	if l.Exceeded {
 		return map[string]string{
@@ -72,13 +134,33 @@ func (l Limit) Headers() map[string]string {
 }
 
 func (rl Ratelimit) Limit(ctx context.Context, r *http.Request) (Limit, error) {
-	if rl.Identifier == nil {
-		rl.Identifier = DefaultIdentifier
+	identifier := hashIdentifier(rl.Identifier, r)
+
+	if rl.Penalty != nil {
+		blocked, err := rl.Penalty.blocked(ctx, rl, identifier)
+
+		if err != nil || blocked.Exceeded {
+			return blocked, err
+		}
+	}
+
+	var limit Limit
+	var err error
+
+	if rl.Strategy == LeakyBucket {
+		limit, err = rl.leakyBucketLimit(ctx, identifier)
+	} else {
+		limit, err = rl.fixedWindowLimit(ctx, identifier)
 	}
 
-	// Hash the identifier for privacy
-	identifier := fmt.Sprintf("%x", sha256.Sum256([]byte(rl.Identifier(r))))
+	if err != nil || !limit.Exceeded || rl.Penalty == nil {
+		return limit, err
+	}
 
+	return rl.Penalty.violation(ctx, rl, limit)
+}
+
+func (rl Ratelimit) fixedWindowLimit(ctx context.Context, identifier string) (Limit, error) {
 	// Check if rate even exists
 	exists, err := State.HotCache.Exists(ctx, rl.Bucket+"-"+identifier)
 
@@ -132,6 +214,46 @@ func (rl Ratelimit) Limit(ctx context.Context, r *http.Request) (Limit, error) {
 	}, nil
 }
 
+// Removes a request from a fixed window without going below zero or recreating an expired window
+//
+// DECR keeps the TTL of the key, so the window still resets on time
+var fixedWindowRefundScript = redis.NewScript(`
+local made = tonumber(redis.call("GET", KEYS[1]) or "0")
+
+if made > 0 then
+	redis.call("DECR", KEYS[1])
+end
+
+return made
+`)
+
+// Refund gives back a request to the ratelimit for the requests identifier, e.g. after the ratelimited operation failed
+//
+// The count never goes below zero, refunding when the ratelimit has already reset is a no-op.
+// The refund is done atomically in a script, so RLState.Redis must be set
+func (rl Ratelimit) Refund(ctx context.Context, r *http.Request) error {
+	identifier := hashIdentifier(rl.Identifier, r)
+
+	if rl.Strategy == LeakyBucket {
+		return rl.leakyBucketRefund(ctx, identifier)
+	}
+
+	if State.Redis == nil {
+		return errors.New("refunds require RLState.Redis to be set")
+	}
+
+	return fixedWindowRefundScript.Run(ctx, State.Redis, []string{State.HotCachePrefix + rl.Bucket + "-" + identifier}).Err()
+}
+
+// Hashes the identifier for privacy, using DefaultIdentifier if fn is nil
+func hashIdentifier(fn func(r *http.Request) string, r *http.Request) string {
+	if fn == nil {
+		fn = DefaultIdentifier
+	}
+
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fn(r))))
+}
+
 func DefaultIdentifier(r *http.Request) string {
 	return r.RemoteAddr
 }
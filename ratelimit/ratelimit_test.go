@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	redishotcache "github.com/topicbotlist/eureka-port/hotcache/redis"
+)
+
+// Sets up State backed by a fresh miniredis
+func setupTestState(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	t.Cleanup(func() { rdb.Close() })
+
+	SetupState(&RLState{
+		HotCache: redishotcache.RedisHotCache[int]{Redis: rdb},
+		Redis:    rdb,
+	})
+
+	return mr
+}
+
+func testRequest(remoteAddr string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	return r
+}
+
+func TestRetryAfterFormats(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		reset   time.Duration
+		seconds string
+		date    string
+	}{
+		{"whole seconds", 90 * time.Second, "90", "Fri, 01 Mar 2024 12:01:30 GMT"},
+		{"fractional seconds round up", 1500 * time.Millisecond, "1.5", "Fri, 01 Mar 2024 12:00:02 GMT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := Limit{Exceeded: true, TimeToReset: tt.reset, Made: 5, MaxRequests: 5, Bucket: "test"}
+
+			if got := l.Headers()["Retry-After"]; got != tt.seconds {
+				t.Errorf("Headers: got Retry-After %q, want %q", got, tt.seconds)
+			}
+
+			headers := l.HeadersAt(now)
+
+			if got := headers["Retry-After"]; got != tt.date {
+				t.Errorf("HeadersAt: got Retry-After %q, want %q", got, tt.date)
+			}
+
+			if headers["Req-Made"] != "5" || headers["Bucket"] != "test" {
+				t.Errorf("HeadersAt dropped the other headers: %v", headers)
+			}
+		})
+	}
+}
+
+func TestRetryAfterNotExceeded(t *testing.T) {
+	l := Limit{TimeToReset: time.Minute, Made: 1, MaxRequests: 5, Bucket: "test"}
+
+	if _, ok := l.HeadersAt(time.Now())["Retry-After"]; ok {
+		t.Fatal("Retry-After set on a ratelimit that was not exceeded")
+	}
+}
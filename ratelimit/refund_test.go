@@ -0,0 +1,207 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	redishotcache "github.com/topicbotlist/eureka-port/hotcache/redis"
+)
+
+func TestRefundFixedWindow(t *testing.T) {
+	setupTestState(t)
+
+	rl := Ratelimit{Bucket: "refund", MaxRequests: 3, Expiry: time.Minute}
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := rl.Limit(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := rl.Refund(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	// Made is the count before this request, so one refunded request leaves 2
+	limit, err := rl.Limit(ctx, r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limit.Made != 2 {
+		t.Fatalf("got %d requests made after a refund, want 2", limit.Made)
+	}
+}
+
+func TestRefundFixedWindowFloor(t *testing.T) {
+	setupTestState(t)
+
+	rl := Ratelimit{Bucket: "refund", MaxRequests: 3, Expiry: time.Minute}
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	// Nothing to refund yet
+	if err := rl.Refund(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rl.Limit(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := rl.Refund(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	limit, err := rl.Limit(ctx, r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limit.Made != 0 {
+		t.Fatalf("got %d requests made, want the count to stop at 0", limit.Made)
+	}
+}
+
+func TestRefundLeakyBucket(t *testing.T) {
+	setupTestState(t)
+
+	rl := Ratelimit{Bucket: "refund", MaxRequests: 3, LeakInterval: time.Minute, Strategy: LeakyBucket}
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	// Refunding an empty bucket is a no-op
+	if err := rl.Refund(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := rl.Limit(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := rl.Refund(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	limit, err := rl.Limit(ctx, r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limit.Exceeded || limit.Made != 3 || limit.Remaining != 0 {
+		t.Fatalf("refunded request was not given back: %+v", limit)
+	}
+
+	if limit, err := rl.Limit(ctx, r); err != nil || !limit.Exceeded {
+		t.Fatalf("bucket should be full again: %+v %v", limit, err)
+	}
+}
+
+func TestRefundFixedWindowExpired(t *testing.T) {
+	mr := setupTestState(t)
+
+	rl := Ratelimit{Bucket: "refund", MaxRequests: 3, Expiry: time.Minute}
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	if _, err := rl.Limit(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	mr.FastForward(2 * time.Minute)
+
+	// The window has reset, so the refund must not recreate the key
+	if err := rl.Refund(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	key := rl.Bucket + "-" + hashIdentifier(rl.Identifier, r)
+
+	if mr.Exists(key) {
+		t.Fatal("refund recreated the expired window")
+	}
+
+	limit, err := rl.Limit(ctx, r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limit.Made != 0 || limit.TimeToReset <= 0 {
+		t.Fatalf("window did not reset cleanly after a late refund: %+v", limit)
+	}
+}
+
+func TestRefundFixedWindowKeepsTTL(t *testing.T) {
+	mr := setupTestState(t)
+
+	rl := Ratelimit{Bucket: "refund", MaxRequests: 3, Expiry: time.Minute}
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := rl.Limit(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := rl.Refund(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	key := rl.Bucket + "-" + hashIdentifier(rl.Identifier, r)
+
+	if ttl := mr.TTL(key); ttl <= 0 {
+		t.Fatalf("refund dropped the TTL of the window, got %s", ttl)
+	}
+}
+
+func TestRefundFixedWindowPrefix(t *testing.T) {
+	mr := setupTestState(t)
+
+	rdb := State.Redis.(*redis.Client)
+
+	SetupState(&RLState{
+		HotCache:       redishotcache.RedisHotCache[int]{Redis: rdb, Prefix: "rl:"},
+		Redis:          rdb,
+		HotCachePrefix: "rl:",
+	})
+
+	rl := Ratelimit{Bucket: "refund", MaxRequests: 3, Expiry: time.Minute}
+	r := testRequest("10.0.0.1:1234")
+	ctx := context.Background()
+
+	if _, err := rl.Limit(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rl.Refund(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := mr.Get("rl:" + rl.Bucket + "-" + hashIdentifier(rl.Identifier, r)); got != "0" {
+		t.Fatalf("got count %q after a refund, want 0", got)
+	}
+}
+
+func TestRefundFixedWindowRequiresRedis(t *testing.T) {
+	setupTestState(t)
+	State.Redis = nil
+
+	rl := Ratelimit{Bucket: "refund", MaxRequests: 3, Expiry: time.Minute}
+
+	if err := rl.Refund(context.Background(), testRequest("10.0.0.1:1234")); err == nil {
+		t.Fatal("expected an error without RLState.Redis")
+	}
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownBucket = errors.New("unknown ratelimit bucket")
+	ErrBucketExists  = errors.New("ratelimit bucket already registered")
+)
+
+// Registry holds named ratelimits so routes can reference a bucket by name instead of defining it inline
+type Registry struct {
+	mu      sync.RWMutex
+	buckets map[string]Ratelimit
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		buckets: make(map[string]Ratelimit),
+	}
+}
+
+// Register adds a ratelimit to the registry under its Bucket name
+func (reg *Registry) Register(rl Ratelimit) error {
+	if rl.Bucket == "" {
+		return errors.New("ratelimit bucket name must be set")
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, ok := reg.buckets[rl.Bucket]; ok {
+		return fmt.Errorf("%w: %s", ErrBucketExists, rl.Bucket)
+	}
+
+	reg.buckets[rl.Bucket] = rl
+
+	return nil
+}
+
+// MustRegister is like Register but panics on error, for use when setting up routes
+func (reg *Registry) MustRegister(rl Ratelimit) {
+	if err := reg.Register(rl); err != nil {
+		panic(err)
+	}
+}
+
+// Get returns the ratelimit registered under a bucket name
+func (reg *Registry) Get(bucket string) (Ratelimit, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	rl, ok := reg.buckets[bucket]
+	return rl, ok
+}
+
+// Limit checks the ratelimit registered under a bucket name, returning ErrUnknownBucket if there is none
+func (reg *Registry) Limit(ctx context.Context, bucket string, r *http.Request) (Limit, error) {
+	rl, ok := reg.Get(bucket)
+
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: %s", ErrUnknownBucket, bucket)
+	}
+
+	return rl.Limit(ctx, r)
+}
+
+// BucketInfo describes a registered bucket, for admin endpoints
+type BucketInfo struct {
+	Bucket       string        `json:"bucket"`
+	MaxRequests  int           `json:"max_requests"`
+	Expiry       time.Duration `json:"expiry"`
+	Strategy     string        `json:"strategy"`
+	LeakInterval time.Duration `json:"leak_interval,omitempty"`
+	Penalty      bool          `json:"penalty"`
+}
+
+// Buckets returns all registered buckets, sorted by name
+func (reg *Registry) Buckets() []BucketInfo {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	buckets := make([]BucketInfo, 0, len(reg.buckets))
+
+	for _, rl := range reg.buckets {
+		buckets = append(buckets, BucketInfo{
+			Bucket:       rl.Bucket,
+			MaxRequests:  rl.MaxRequests,
+			Expiry:       rl.Expiry,
+			Strategy:     rl.Strategy.String(),
+			LeakInterval: rl.LeakInterval,
+			Penalty:      rl.Penalty != nil,
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Bucket < buckets[j].Bucket
+	})
+
+	return buckets
+}
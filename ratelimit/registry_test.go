@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistryLimit(t *testing.T) {
+	setupTestState(t)
+
+	reg := NewRegistry()
+	reg.MustRegister(Ratelimit{Bucket: "login", MaxRequests: 1, Expiry: time.Minute})
+	reg.MustRegister(Ratelimit{Bucket: "search", MaxRequests: 5, Expiry: time.Minute})
+	reg.MustRegister(Ratelimit{Bucket: "upload", MaxRequests: 2, LeakInterval: time.Minute, Strategy: LeakyBucket})
+
+	ctx := context.Background()
+	r := testRequest("10.0.0.1:1234")
+
+	// Fixed window allows MaxRequests + 1 as Made is counted before the request
+	for i := 0; i < 2; i++ {
+		if limit, err := reg.Limit(ctx, "login", r); err != nil || limit.Exceeded {
+			t.Fatalf("login %d: %+v %v", i, limit, err)
+		}
+	}
+
+	if limit, err := reg.Limit(ctx, "login", r); err != nil || !limit.Exceeded || limit.Bucket != "login" {
+		t.Fatalf("login over the limit: %+v %v", limit, err)
+	}
+
+	// Buckets are independent
+	if limit, err := reg.Limit(ctx, "search", r); err != nil || limit.Exceeded || limit.Bucket != "search" {
+		t.Fatalf("search: %+v %v", limit, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		reg.Limit(ctx, "upload", r)
+	}
+
+	if limit, err := reg.Limit(ctx, "upload", r); err != nil || !limit.Exceeded {
+		t.Fatalf("upload over the limit: %+v %v", limit, err)
+	}
+
+	if _, err := reg.Limit(ctx, "missing", r); !errors.Is(err, ErrUnknownBucket) {
+		t.Fatalf("got %v, want ErrUnknownBucket", err)
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	reg := NewRegistry()
+
+	if err := reg.Register(Ratelimit{MaxRequests: 1}); err == nil {
+		t.Fatal("registered a ratelimit without a bucket name")
+	}
+
+	if err := reg.Register(Ratelimit{Bucket: "login", MaxRequests: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reg.Register(Ratelimit{Bucket: "login", MaxRequests: 2}); !errors.Is(err, ErrBucketExists) {
+		t.Fatalf("got %v, want ErrBucketExists", err)
+	}
+}
+
+func TestRegistryBuckets(t *testing.T) {
+	reg := NewRegistry()
+	reg.MustRegister(Ratelimit{Bucket: "search", MaxRequests: 5, Expiry: time.Minute})
+	reg.MustRegister(Ratelimit{Bucket: "login", MaxRequests: 1, Expiry: time.Hour, Penalty: &Penalty{}})
+	reg.MustRegister(Ratelimit{Bucket: "upload", MaxRequests: 2, LeakInterval: time.Second, Strategy: LeakyBucket})
+
+	want := []BucketInfo{
+		{Bucket: "login", MaxRequests: 1, Expiry: time.Hour, Strategy: "fixed_window", Penalty: true},
+		{Bucket: "search", MaxRequests: 5, Expiry: time.Minute, Strategy: "fixed_window"},
+		{Bucket: "upload", MaxRequests: 2, Strategy: "leaky_bucket", LeakInterval: time.Second},
+	}
+
+	got := reg.Buckets()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("bucket %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Responder builds an error body from a message and context
+//
+// uapi's UAPIDefaultResponder satisfies this, so State.DefaultResponder from uapi can be passed directly
+type Responder interface {
+	New(msg string, ctx map[string]string) any
+}
+
+// Response is the response to send when a ratelimit is exceeded
+//
+// This maps directly onto uapi.HttpResponse without ratelimit depending on uapi
+type Response struct {
+	// Status is the HTTP status code to send
+	Status int
+	// Headers to set
+	Headers map[string]string
+	// Json body to be sent to the client
+	Json any
+}
+
+// Response returns the response to send for an exceeded limit, using OnExceeded if set and
+// DefaultExceededResponse otherwise
+func (rl Ratelimit) Response(l Limit, responder Responder) Response {
+	if rl.OnExceeded != nil {
+		return rl.OnExceeded(l)
+	}
+
+	return DefaultExceededResponse(l, responder)
+}
+
+// DefaultExceededResponse returns a 429 with the ratelimit headers and an error body built by responder
+//
+// If responder is nil, the body is a plain {"message": "..."} object
+func DefaultExceededResponse(l Limit, responder Responder) Response {
+	msg := "You are being ratelimited. Please try again in " + strconv.FormatFloat(l.TimeToReset.Seconds(), 'f', 2, 64) + " seconds"
+
+	var body any
+
+	if responder != nil {
+		body = responder.New(msg, map[string]string{
+			"retry_after": strconv.FormatFloat(l.TimeToReset.Seconds(), 'f', -1, 64),
+			"bucket":      l.Bucket,
+		})
+	} else {
+		body = map[string]string{
+			"message": msg,
+		}
+	}
+
+	return Response{
+		Status:  http.StatusTooManyRequests,
+		Headers: l.Headers(),
+		Json:    body,
+	}
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+type testResponder struct{}
+
+func (testResponder) New(msg string, ctx map[string]string) any {
+	return map[string]any{"message": msg, "context": ctx}
+}
+
+func TestResponseOnExceeded(t *testing.T) {
+	l := Limit{Exceeded: true, TimeToReset: 3 * time.Second, Bucket: "test"}
+
+	var got Limit
+
+	rl := Ratelimit{
+		Bucket: "test",
+		OnExceeded: func(l Limit) Response {
+			got = l
+			return Response{Status: http.StatusServiceUnavailable, Json: "custom"}
+		},
+	}
+
+	resp := rl.Response(l, testResponder{})
+
+	if resp.Status != http.StatusServiceUnavailable || resp.Json != "custom" {
+		t.Fatalf("got %+v, want the OnExceeded response", resp)
+	}
+
+	if got != l {
+		t.Fatalf("OnExceeded got %+v, want %+v", got, l)
+	}
+}
+
+func TestResponseDefault(t *testing.T) {
+	l := Limit{Exceeded: true, TimeToReset: 1500 * time.Millisecond, Made: 5, MaxRequests: 5, Bucket: "test"}
+
+	resp := Ratelimit{Bucket: "test"}.Response(l, testResponder{})
+
+	if resp.Status != http.StatusTooManyRequests {
+		t.Fatalf("got status %d, want 429", resp.Status)
+	}
+
+	if resp.Headers["Retry-After"] != "1.5" {
+		t.Fatalf("got Retry-After %q, want 1.5", resp.Headers["Retry-After"])
+	}
+
+	body, ok := resp.Json.(map[string]any)
+
+	if !ok {
+		t.Fatalf("body is %T, want the responder's body", resp.Json)
+	}
+
+	if ctx := body["context"].(map[string]string); ctx["retry_after"] != "1.5" || ctx["bucket"] != "test" {
+		t.Fatalf("unexpected body context %v", ctx)
+	}
+
+	// Without a responder a plain message is sent
+	if _, ok := (Ratelimit{}).Response(l, nil).Json.(map[string]string)["message"]; !ok {
+		t.Fatal("expected a plain message body without a responder")
+	}
+}
@@ -0,0 +1,74 @@
+package shellcli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func isEnvNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// Expands $VAR and ${VAR} in s, \$ is left as a literal $
+//
+// If errOnUnset is true, an error is returned for unset variables, otherwise they expand to an empty string
+func expandEnv(s string, errOnUnset bool) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c == '\\' && i+1 < len(s) && s[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+
+		if c != '$' {
+			b.WriteByte(c)
+			continue
+		}
+
+		var name string
+		end := i + 1
+
+		if end < len(s) && s[end] == '{' {
+			closing := strings.IndexByte(s[end:], '}')
+
+			if closing < 0 {
+				return "", fmt.Errorf("unterminated ${ in %s", s)
+			}
+
+			name = s[end+1 : end+closing]
+			end += closing + 1
+		} else {
+			for end < len(s) && isEnvNameChar(s[end]) {
+				end++
+			}
+
+			name = s[i+1 : end]
+		}
+
+		// A lone $ is kept as is
+		if name == "" {
+			b.WriteByte('$')
+			continue
+		}
+
+		v, ok := os.LookupEnv(name)
+
+		if !ok && errOnUnset {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+
+		b.WriteString(v)
+		i = end - 1
+	}
+
+	return b.String(), nil
+}
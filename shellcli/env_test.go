@@ -0,0 +1,67 @@
+package shellcli
+
+import (
+	"testing"
+)
+
+func TestExecEnvExpansion(t *testing.T) {
+	t.Setenv("SHELLCLI_TOKEN", "s3cret")
+	t.Setenv("SHELLCLI_EMPTY", "")
+
+	tests := []struct {
+		name string
+		arg  string
+		want string
+	}{
+		{"set", "$SHELLCLI_TOKEN", "s3cret"},
+		{"braced", "${SHELLCLI_TOKEN}suffix", "s3cretsuffix"},
+		{"named argument", "first=token=$SHELLCLI_TOKEN", "token=s3cret"},
+		{"unset", "a$SHELLCLI_UNSET-b", "a-b"},
+		{"set but empty", "[$SHELLCLI_EMPTY]", "[]"},
+		{"escaped", `\$SHELLCLI_TOKEN`, "$SHELLCLI_TOKEN"},
+		{"lone dollar", "cost: $", "cost: $"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]string
+
+			shell, _ := newTestShell(t, &got)
+
+			if err := shell.Exec([]string{"echo", tt.arg}); err != nil {
+				t.Fatal(err)
+			}
+
+			if got["first"] != tt.want {
+				t.Fatalf("got %q, want %q", got["first"], tt.want)
+			}
+		})
+	}
+}
+
+func TestExecEnvExpansionOptions(t *testing.T) {
+	t.Setenv("SHELLCLI_TOKEN", "s3cret")
+
+	var got map[string]string
+
+	shell, _ := newTestShell(t, &got)
+	shell.ErrorOnUnsetEnv = true
+
+	if err := shell.Exec([]string{"echo", "$SHELLCLI_UNSET"}); err == nil {
+		t.Fatal("expected an error for an unset variable")
+	}
+
+	if err := shell.Exec([]string{"echo", "${SHELLCLI_TOKEN"}); err == nil {
+		t.Fatal("expected an error for an unterminated ${")
+	}
+
+	shell.DisableEnvExpansion = true
+
+	if err := shell.Exec([]string{"echo", "$SHELLCLI_TOKEN"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["first"] != "$SHELLCLI_TOKEN" {
+		t.Fatalf("got %q with expansion disabled", got["first"])
+	}
+}
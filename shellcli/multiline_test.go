@@ -0,0 +1,63 @@
+package shellcli
+
+import (
+	"strings"
+	"testing"
+)
+
+// Prompts once with the given input, returning the args the command ran with
+func promptArgs(t *testing.T, input string) (map[string]string, string) {
+	t.Helper()
+
+	var got map[string]string
+
+	shell, out := newTestShell(t, &got)
+	shell.In = strings.NewReader(input)
+	shell.Prompter = func(*ShellCli[testData]) string { return "ops$ " }
+
+	if err := shell.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shell.Prompt(); err != nil {
+		t.Fatal(err)
+	}
+
+	return got, out.String()
+}
+
+func TestPromptBackslashContinuation(t *testing.T) {
+	got, out := promptArgs(t, "echo one \\\n    two\n")
+
+	if got["first"] != "one" || got["second"] != "two" {
+		t.Fatalf("unexpected args %v", got)
+	}
+
+	if !strings.Contains(out, ContinuationPrompt) {
+		t.Fatalf("no continuation prompt in %q", out)
+	}
+}
+
+func TestPromptQuotedNewline(t *testing.T) {
+	got, _ := promptArgs(t, "echo '{\n  \"name\": \"eureka\"\n}' second\n")
+
+	if want := "{\n  \"name\": \"eureka\"\n}"; got["first"] != want {
+		t.Fatalf("got first %q, want %q", got["first"], want)
+	}
+
+	if got["second"] != "second" {
+		t.Fatalf("unexpected args %v", got)
+	}
+}
+
+func TestPromptSingleLine(t *testing.T) {
+	got, out := promptArgs(t, "echo a b\nignored\n")
+
+	if got["first"] != "a" || got["second"] != "b" {
+		t.Fatalf("unexpected args %v", got)
+	}
+
+	if strings.Contains(out, ContinuationPrompt) {
+		t.Fatalf("continuation prompt shown for a complete line: %q", out)
+	}
+}
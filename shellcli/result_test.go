@@ -0,0 +1,69 @@
+package shellcli
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type sumResult struct {
+	Args  map[string]string
+	Total int
+}
+
+func TestExecResult(t *testing.T) {
+	errNoBots := errors.New("no bots")
+
+	shell := &ShellCli[testData]{
+		Data: &testData{},
+		Commands: map[string]*Command[testData]{
+			"count": {
+				Args: [][3]string{{"kind", "What to count", ""}},
+				RunResult: func(ctx context.Context, a *ShellCli[testData], args map[string]string) (any, error) {
+					if args["kind"] == "bots" {
+						return nil, errNoBots
+					}
+
+					return sumResult{Args: args, Total: 42}, nil
+				},
+			},
+			"noop": {
+				Run: func(a *ShellCli[testData], args map[string]string) error { return nil },
+			},
+		},
+	}
+
+	if err := shell.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := shell.ExecResult([]string{"count", "users"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, ok := res.(sumResult)
+
+	if !ok {
+		t.Fatalf("got result %T, want sumResult", res)
+	}
+
+	if sum.Total != 42 || sum.Args["kind"] != "users" {
+		t.Fatalf("unexpected result %+v", sum)
+	}
+
+	if _, err := shell.ExecResult([]string{"count", "bots"}); !errors.Is(err, errNoBots) {
+		t.Fatalf("got %v, want the command error", err)
+	}
+
+	// Exec discards the result but keeps the error
+	if err := shell.Exec([]string{"count", "bots"}); !errors.Is(err, errNoBots) {
+		t.Fatalf("Exec: got %v, want the command error", err)
+	}
+
+	// Commands without RunResult have no result
+	if res, err := shell.ExecResult([]string{"noop"}); err != nil || res != nil {
+		t.Fatalf("got %v, %v, want nil, nil", res, err)
+	}
+}
@@ -2,10 +2,16 @@ package shellcli
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-andiamo/splitter"
 )
@@ -14,10 +20,101 @@ import (
 type ShellCli[T any] struct {
 	Commands        map[string]*Command[T]
 	Splitter        splitter.Splitter
-	ArgSplitter     splitter.Splitter
+	ArgSplitter     splitter.Splitter // Deprecated: no longer used by Exec, arguments are split on their first = instead
 	CaseInsensitive bool
 	Prompter        func(*ShellCli[T]) string
 	Data            *T
+
+	// Where prompts and command output are written, defaults to os.Stdout
+	//
+	// Commands should write through Output() so their output is captured by Transcript
+	Out io.Writer
+
+	// Where commands are read from, defaults to os.Stdin
+	In io.Reader
+
+	// If set, a timestamped transcript of each prompt, entered command and its output is written here
+	Transcript io.Writer
+
+	// The default maximum time a command may run for, if zero, commands have no timeout
+	DefaultTimeout time.Duration
+
+	// Disables expansion of $VAR and ${VAR} in arguments
+	DisableEnvExpansion bool
+
+	// Return an error when an argument references an unset environment variable instead of expanding it to an empty string
+	ErrorOnUnsetEnv bool
+
+	reader      *bufio.Reader
+	transcriptW *timestampWriter
+}
+
+// Writes a timestamp at the start of each line
+type timestampWriter struct {
+	w         io.Writer
+	midLine   bool
+	timestamp func() string
+}
+
+func (t *timestampWriter) Write(p []byte) (int, error) {
+	n := len(p)
+
+	for len(p) > 0 {
+		if !t.midLine {
+			if _, err := io.WriteString(t.w, "["+t.timestamp()+"] "); err != nil {
+				return 0, err
+			}
+
+			t.midLine = true
+		}
+
+		line := p
+		i := bytes.IndexByte(p, '\n')
+
+		if i >= 0 {
+			line = p[:i+1]
+			t.midLine = false
+		}
+
+		if _, err := t.w.Write(line); err != nil {
+			return 0, err
+		}
+
+		p = p[len(line):]
+	}
+
+	return n, nil
+}
+
+// Output returns the writer commands should print to
+//
+// This writes to Out and, if set, Transcript
+func (a *ShellCli[T]) Output() io.Writer {
+	var out io.Writer = os.Stdout
+
+	if a.Out != nil {
+		out = a.Out
+	}
+
+	if a.Transcript != nil {
+		return io.MultiWriter(out, a.transcript())
+	}
+
+	return out
+}
+
+func (a *ShellCli[T]) transcript() io.Writer {
+	// Reused so partial lines written across calls share a single timestamp
+	if a.transcriptW == nil || a.transcriptW.w != a.Transcript {
+		a.transcriptW = &timestampWriter{
+			w: a.Transcript,
+			timestamp: func() string {
+				return time.Now().Format(time.RFC3339)
+			},
+		}
+	}
+
+	return a.transcriptW
 }
 
 // Returns a help command
@@ -29,27 +126,59 @@ func (s *ShellCli[T]) Help() *Command[T] {
 		},
 		Run: func(a *ShellCli[T], args map[string]string) error {
 			if arg, ok := args["command"]; ok && arg != "" {
+				if a.CaseInsensitive {
+					arg = strings.ToLower(arg)
+				}
+
 				cmd, ok := a.Commands[arg]
 
 				if !ok {
 					return fmt.Errorf("unknown command: %s", arg)
 				}
 
-				fmt.Println("Command: ", arg)
-				fmt.Println("Description: ", cmd.Description)
-				fmt.Println("Arguments: ")
+				out := a.Output()
+
+				fmt.Fprintln(out, "Command: ", arg)
+				fmt.Fprintln(out, "Description: ", cmd.Description)
+
+				if len(cmd.Aliases) > 0 {
+					fmt.Fprintln(out, "Aliases: ", strings.Join(cmd.Aliases, ", "))
+				}
+
+				fmt.Fprintln(out, "Arguments: ")
 
 				for _, cmd := range cmd.Args {
-					fmt.Print("  ", cmd[0], " : ", cmd[1], " (default: ", cmd[2], ")\n")
+					fmt.Fprint(out, "  ", cmd[0], " : ", cmd[1], " (default: ", cmd[2], ")\n")
 				}
 			} else {
-				fmt.Println("Commands: ")
+				out := a.Output()
+
+				fmt.Fprintln(out, "Commands: ")
+
+				names := make([]string, 0, len(a.Commands))
 
-				for cmd, desc := range a.Commands {
-					fmt.Print("  ", cmd, ": ", desc.Description, "\n")
+				for cmd := range a.Commands {
+					names = append(names, cmd)
 				}
 
-				fmt.Println("Use 'help <command>' to get help for a specific command")
+				sort.Strings(names)
+
+				for _, cmd := range names {
+					desc := a.Commands[cmd]
+
+					if desc.isAlias(cmd) {
+						continue
+					}
+
+					if len(desc.Aliases) > 0 {
+						fmt.Fprint(out, "  ", cmd, " (", strings.Join(desc.Aliases, ", "), "): ", desc.Description, "\n")
+						continue
+					}
+
+					fmt.Fprint(out, "  ", cmd, ": ", desc.Description, "\n")
+				}
+
+				fmt.Fprintln(out, "Use 'help <command>' to get help for a specific command")
 			}
 
 			return nil
@@ -60,10 +189,63 @@ func (s *ShellCli[T]) Help() *Command[T] {
 // Command is a command for the shell client
 type Command[T any] struct {
 	Description string
-	Args        [][3]string // Map of argument to the description and default value
+	Args        [][3]string // Map of argument to the description and default value, defaults are applied by Exec
 	Run         func(a *ShellCli[T], args map[string]string) error
+
+	// Alternative names for the command, registered by AddCommand
+	Aliases []string
+
+	// Like Run but receives a context that is cancelled when the command times out, used over Run if set
+	RunContext func(ctx context.Context, a *ShellCli[T], args map[string]string) error
+
+	// The maximum time the command may run for, overrides ShellCli.DefaultTimeout. If zero, DefaultTimeout is used
+	//
+	// Commands using Run (not RunContext) cannot be cancelled, so they keep running in the background after timing out
+	Timeout time.Duration
+
+	// Like RunContext but also returns a structured result, surfaced by ExecResult. Used over Run and RunContext if set
+	//
+	// Useful when embedding the shell in a larger program that needs the result rather than printed output
+	RunResult func(ctx context.Context, a *ShellCli[T], args map[string]string) (any, error)
+}
+
+// Calls whichever run function the command has set
+func (c *Command[T]) call(ctx context.Context, a *ShellCli[T], args map[string]string) (any, error) {
+	switch {
+	case c.RunResult != nil:
+		return c.RunResult(ctx, a, args)
+	case c.RunContext != nil:
+		return nil, c.RunContext(ctx, a, args)
+	default:
+		return nil, c.Run(a, args)
+	}
+}
+
+// Returns whether name is a declared argument of the command
+func (c *Command[T]) hasArg(name string) bool {
+	for _, arg := range c.Args {
+		if arg[0] == name {
+			return true
+		}
+	}
+
+	return false
 }
 
+// Returns whether name is one of the commands aliases
+func (c *Command[T]) isAlias(name string) bool {
+	for _, alias := range c.Aliases {
+		if strings.EqualFold(alias, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrCommandTimeout is returned by Exec when a command exceeds its timeout
+var ErrCommandTimeout = errors.New("command timed out")
+
 // Init initializes the shell client
 func (a *ShellCli[T]) Init() error {
 	var err error
@@ -88,8 +270,16 @@ func (a *ShellCli[T]) Init() error {
 
 // Exec executes a command
 func (a *ShellCli[T]) Exec(cmd []string) error {
+	_, err := a.ExecResult(cmd)
+	return err
+}
+
+// ExecResult is like Exec but also returns the result of commands using RunResult
+//
+// For other commands, the result is always nil
+func (a *ShellCli[T]) ExecResult(cmd []string) (any, error) {
 	if len(cmd) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	cmdName := cmd[0]
@@ -101,7 +291,7 @@ func (a *ShellCli[T]) Exec(cmd []string) error {
 	cmdData, ok := a.Commands[cmdName]
 
 	if !ok {
-		return fmt.Errorf("unknown command: %s", cmd[0])
+		return nil, fmt.Errorf("unknown command: %s", cmd[0])
 	}
 
 	args := cmd[1:]
@@ -109,44 +299,109 @@ func (a *ShellCli[T]) Exec(cmd []string) error {
 	argMap := make(map[string]string)
 
 	for i, arg := range args {
-		fields, err := a.ArgSplitter.Split(arg)
+		if arg == "" {
+			continue
+		}
 
-		if err != nil {
-			return fmt.Errorf("error splitting argument: %s", err)
+		// Only name=value where name is a declared argument is named, so positional values may contain =
+		name, value, named := strings.Cut(arg, "=")
+
+		if !named || !cmdData.hasArg(name) {
+			name, value, named = "", arg, false
 		}
 
-		if len(fields) == 1 {
-			if len(cmdData.Args) <= i {
-				fmt.Println("WARNING: extra argument: ", fields[0])
-				continue
+		if !a.DisableEnvExpansion {
+			var err error
+
+			// Only the value is expanded, not the argument name
+			value, err = expandEnv(value, a.ErrorOnUnsetEnv)
+
+			if err != nil {
+				return nil, fmt.Errorf("error expanding argument: %s", err)
 			}
+		}
 
-			argMap[cmdData.Args[i][0]] = fields[0]
+		if named {
+			argMap[name] = value
+			continue
+		}
 
+		if len(cmdData.Args) <= i {
+			fmt.Fprintln(a.Output(), "WARNING: extra argument: ", value)
 			continue
 		}
 
-		if len(fields) != 2 {
-			return fmt.Errorf("invalid argument: %s", arg)
+		argMap[cmdData.Args[i][0]] = value
+	}
+
+	// Apply defaults for arguments that weren't passed, an empty default means the argument has none
+	for _, arg := range cmdData.Args {
+		if _, ok := argMap[arg[0]]; !ok && arg[2] != "" {
+			argMap[arg[0]] = arg[2]
 		}
+	}
+
+	return a.run(cmdData, argMap)
+}
+
+// Runs the command, enforcing its timeout
+func (a *ShellCli[T]) run(cmdData *Command[T], argMap map[string]string) (any, error) {
+	timeout := cmdData.Timeout
 
-		argMap[fields[0]] = fields[1]
+	if timeout == 0 {
+		timeout = a.DefaultTimeout
 	}
 
-	err := cmdData.Run(a, argMap)
+	ctx := context.Background()
 
-	if err != nil {
-		return err
+	if timeout <= 0 {
+		return cmdData.call(ctx, a, argMap)
 	}
 
-	return nil
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		value any
+		err   error
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		value, err := cmdData.call(ctx, a, argMap)
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%w after %s", ErrCommandTimeout, timeout)
+	}
 }
 
 func (a *ShellCli[T]) Prompt() error {
-	fmt.Print(a.Prompter(a))
+	var out io.Writer = os.Stdout
+
+	if a.Out != nil {
+		out = a.Out
+	}
 
-	buf := bufio.NewReader(os.Stdin)
-	var command, err = buf.ReadString('\n')
+	prompt := a.Prompter(a)
+	fmt.Fprint(out, prompt)
+
+	if a.reader == nil {
+		var in io.Reader = os.Stdin
+
+		if a.In != nil {
+			in = a.In
+		}
+
+		a.reader = bufio.NewReader(in)
+	}
+
+	command, err := a.readCommand(out)
 
 	if err != nil {
 		return err
@@ -154,13 +409,28 @@ func (a *ShellCli[T]) Prompt() error {
 
 	command = strings.TrimSpace(command)
 
+	if a.Transcript != nil {
+		fmt.Fprintln(a.transcript(), prompt+command)
+	}
+
 	tokens, err := a.Splitter.Split(command)
 
 	if err != nil {
 		return fmt.Errorf("error splitting command: %s", err)
 	}
 
-	if len(tokens) == 0 || tokens[0] == "" {
+	// Repeated spaces (e.g. from indented continuation lines) produce empty tokens
+	args := tokens[:0]
+
+	for _, token := range tokens {
+		if token != "" {
+			args = append(args, token)
+		}
+	}
+
+	tokens = args
+
+	if len(tokens) == 0 {
 		return nil
 	}
 
@@ -173,6 +443,55 @@ func (a *ShellCli[T]) Prompt() error {
 	return nil
 }
 
+// The prompt shown while reading continuation lines
+var ContinuationPrompt = "> "
+
+// Reads a command, which may span multiple lines if a line ends with a backslash or a quote is left open
+func (a *ShellCli[T]) readCommand(out io.Writer) (string, error) {
+	var command strings.Builder
+
+	for {
+		line, err := a.reader.ReadString('\n')
+
+		if err != nil {
+			return "", err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		if strings.HasSuffix(line, "\\") {
+			command.WriteString(strings.TrimSuffix(line, "\\"))
+		} else {
+			command.WriteString(line)
+
+			if !openQuote(command.String()) {
+				return command.String(), nil
+			}
+
+			// Keep the newline as part of the quoted argument
+			command.WriteString("\n")
+		}
+
+		fmt.Fprint(out, ContinuationPrompt)
+	}
+}
+
+// Returns whether s ends inside a single or double quoted string
+func openQuote(s string) bool {
+	var quote rune
+
+	for _, c := range s {
+		switch {
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case c == quote:
+			quote = 0
+		}
+	}
+
+	return quote != 0
+}
+
 // AddCommand adds a command to the shell client
 //
 // It is recommended to use this to add a command over directly modifying the Commands map
@@ -183,6 +502,14 @@ func (a *ShellCli[T]) AddCommand(name string, cmd *Command[T]) {
 	}
 
 	a.Commands[name] = cmd
+
+	for _, alias := range cmd.Aliases {
+		if a.CaseInsensitive {
+			alias = strings.ToLower(alias)
+		}
+
+		a.Commands[alias] = cmd
+	}
 }
 
 // Run constantly prompts for input and os.Exit()'s on interrupt signal
@@ -201,7 +528,7 @@ func (a *ShellCli[T]) Run() {
 			err = a.Prompt()
 
 			if err != nil {
-				fmt.Println("Error: ", err)
+				fmt.Fprintln(a.Output(), "Error: ", err)
 			}
 		}
 	}()
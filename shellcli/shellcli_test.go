@@ -0,0 +1,351 @@
+package shellcli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testData struct{}
+
+// Returns a shell with an echo command recording the args it was run with
+func newTestShell(t *testing.T, got *map[string]string) (*ShellCli[testData], *bytes.Buffer) {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+
+	shell := &ShellCli[testData]{
+		Out:  out,
+		Data: &testData{},
+		Commands: map[string]*Command[testData]{
+			"echo": {
+				Description: "Echoes its arguments",
+				Args: [][3]string{
+					{"first", "The first argument", ""},
+					{"second", "The second argument", "fallback"},
+				},
+				Run: func(a *ShellCli[testData], args map[string]string) error {
+					*got = args
+					return nil
+				},
+			},
+		},
+	}
+
+	if err := shell.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	return shell, out
+}
+
+func TestExecExtraPositionalArgs(t *testing.T) {
+	var got map[string]string
+
+	shell, out := newTestShell(t, &got)
+
+	err := shell.Exec([]string{"echo", "a", "b", "c", "d"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["first"] != "a" || got["second"] != "b" || len(got) != 2 {
+		t.Fatalf("unexpected args %v", got)
+	}
+
+	for _, extra := range []string{"c", "d"} {
+		if !strings.Contains(out.String(), "extra argument:  "+extra) {
+			t.Errorf("no warning for extra argument %s in %q", extra, out.String())
+		}
+	}
+}
+
+func TestExecEmptyArgs(t *testing.T) {
+	var got map[string]string
+
+	shell, _ := newTestShell(t, &got)
+
+	err := shell.Exec([]string{"echo", "", "a", ""})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Empty arguments are skipped but still take their position
+	if got["second"] != "a" || got["first"] != "" {
+		t.Fatalf("unexpected args %v", got)
+	}
+
+	if err := shell.Exec([]string{"echo", ""}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["second"] != "fallback" {
+		t.Fatalf("default not applied after an empty argument, got %v", got)
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	out := &bytes.Buffer{}
+	transcript := &bytes.Buffer{}
+
+	shell := &ShellCli[testData]{
+		Out:        out,
+		In:         strings.NewReader("say hello\nsay 'good bye'\n"),
+		Transcript: transcript,
+		Data:       &testData{},
+		Prompter:   func(*ShellCli[testData]) string { return "ops> " },
+	}
+
+	shell.AddCommand("say", &Command[testData]{
+		Description: "Prints its argument",
+		Args:        [][3]string{{"text", "The text to print", ""}},
+		Run: func(a *ShellCli[testData], args map[string]string) error {
+			fmt.Fprintln(a.Output(), args["text"])
+			return nil
+		},
+	})
+
+	if err := shell.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := shell.Prompt(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(transcript.String(), "\n"), "\n")
+	want := []string{"ops> say hello", "hello", "ops> say 'good bye'", "good bye"}
+
+	if len(lines) != len(want) {
+		t.Fatalf("got transcript %q, want %d lines", transcript.String(), len(want))
+	}
+
+	timestamp := regexp.MustCompile(`^\[\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}[^\]]*\] `)
+
+	for i, line := range lines {
+		if !timestamp.MatchString(line) {
+			t.Errorf("line %q has no timestamp", line)
+		}
+
+		if got := timestamp.ReplaceAllString(line, ""); got != want[i] {
+			t.Errorf("got line %q, want %q", got, want[i])
+		}
+	}
+
+	// Output still goes to Out, without timestamps
+	if !strings.Contains(out.String(), "hello\n") || strings.Contains(out.String(), "[") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+}
+
+func TestCommandTimeout(t *testing.T) {
+	shell := &ShellCli[testData]{Out: &bytes.Buffer{}, Data: &testData{}, DefaultTimeout: time.Minute}
+
+	shell.AddCommand("fast", &Command[testData]{
+		Timeout: time.Second,
+		Run:     func(a *ShellCli[testData], args map[string]string) error { return nil },
+	})
+
+	cancelled := make(chan struct{})
+
+	shell.AddCommand("hang", &Command[testData]{
+		Timeout: 10 * time.Millisecond,
+		RunContext: func(ctx context.Context, a *ShellCli[testData], args map[string]string) error {
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		},
+	})
+
+	if err := shell.Exec([]string{"fast"}); err != nil {
+		t.Fatalf("command within its timeout failed: %s", err)
+	}
+
+	if err := shell.Exec([]string{"hang"}); !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("got %v, want ErrCommandTimeout", err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("the context of the timed out command was not cancelled")
+	}
+}
+
+func TestCommandDefaultTimeout(t *testing.T) {
+	shell := &ShellCli[testData]{Out: &bytes.Buffer{}, Data: &testData{}, DefaultTimeout: 10 * time.Millisecond}
+
+	block := make(chan struct{})
+	defer close(block)
+
+	shell.AddCommand("hang", &Command[testData]{
+		Run: func(a *ShellCli[testData], args map[string]string) error {
+			<-block
+			return nil
+		},
+	})
+
+	if err := shell.Exec([]string{"hang"}); !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("got %v, want ErrCommandTimeout", err)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	out := &bytes.Buffer{}
+	ran := 0
+
+	shell := &ShellCli[testData]{Out: out, Data: &testData{}, CaseInsensitive: true}
+
+	shell.AddCommand("help", shell.Help())
+	shell.AddCommand("quit", &Command[testData]{
+		Description: "Exits the shell",
+		Aliases:     []string{"q", "EXIT"},
+		Run: func(a *ShellCli[testData], args map[string]string) error {
+			ran++
+			return nil
+		},
+	})
+
+	if err := shell.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"quit", "q", "exit", "Exit"} {
+		if err := shell.Exec([]string{name}); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+	}
+
+	if ran != 4 {
+		t.Fatalf("command ran %d times, want 4", ran)
+	}
+
+	if err := shell.Exec([]string{"help"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "  quit (q, EXIT): Exits the shell\n") {
+		t.Fatalf("aliases not shown in help: %q", out.String())
+	}
+
+	// Aliases are listed with their command, not on their own
+	if strings.Contains(out.String(), "  q:") || strings.Contains(out.String(), "  exit:") {
+		t.Fatalf("alias listed as a command: %q", out.String())
+	}
+
+	out.Reset()
+
+	if err := shell.Exec([]string{"help", "q"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "Aliases:  q, EXIT") {
+		t.Fatalf("aliases not shown in command help: %q", out.String())
+	}
+}
+
+func TestHelpOrder(t *testing.T) {
+	out := &bytes.Buffer{}
+	shell := &ShellCli[testData]{Out: out, Data: &testData{}}
+
+	shell.AddCommand("help", shell.Help())
+
+	for _, name := range []string{"zeta", "alpha", "mu", "beta", "omega", "gamma"} {
+		shell.AddCommand(name, &Command[testData]{Description: name})
+	}
+
+	want := "Commands: \n  alpha: alpha\n  beta: beta\n  gamma: gamma\n  help: Get help for a command\n  mu: mu\n  omega: omega\n  zeta: zeta\n"
+
+	// Map iteration order is randomized, so a few runs would catch unsorted output
+	for i := 0; i < 10; i++ {
+		out.Reset()
+
+		if err := shell.Exec([]string{"help"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.HasPrefix(out.String(), want) {
+			t.Fatalf("got %q, want the commands sorted", out.String())
+		}
+	}
+}
+
+func TestExecNamedAndPositionalArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  []string
+		want map[string]string
+	}{
+		{"positional containing =", []string{"echo", "https://example.com/?a=1&b=2"}, map[string]string{"first": "https://example.com/?a=1&b=2", "second": "fallback"}},
+		{"undeclared name", []string{"echo", "third=x", "y"}, map[string]string{"first": "third=x", "second": "y"}},
+		{"named", []string{"echo", "second=b", "first=a"}, map[string]string{"first": "a", "second": "b"}},
+		{"named value containing =", []string{"echo", "first=key=value"}, map[string]string{"first": "key=value", "second": "fallback"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]string
+
+			shell, _ := newTestShell(t, &got)
+
+			if err := shell.Exec(tt.cmd); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestExecDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  []string
+		want map[string]string
+	}{
+		{"omitted", []string{"echo", "a"}, map[string]string{"first": "a", "second": "fallback"}},
+		{"positional override", []string{"echo", "a", "b"}, map[string]string{"first": "a", "second": "b"}},
+		{"named override", []string{"echo", "second=b"}, map[string]string{"second": "b"}},
+		// Arguments without a default are left unset
+		{"no default", []string{"echo"}, map[string]string{"second": "fallback"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]string
+
+			shell, _ := newTestShell(t, &got)
+
+			if err := shell.Exec(tt.cmd); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
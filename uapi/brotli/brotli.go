@@ -0,0 +1,47 @@
+// Brotli compressor for uapi
+//
+// This lives in its own package so projects that don't want the brotli dependency can leave it out
+package brotli
+
+import (
+	"bytes"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Compressor compresses responses using brotli, add it to uapi.UAPIState.Compressors
+// before uapi.GzipCompressor to prefer it when clients accept both
+type Compressor struct {
+	// Compression level, defaults to brotli.DefaultCompression
+	Level int
+}
+
+func (c Compressor) Encoding() string {
+	return "br"
+}
+
+func (c Compressor) Compress(data []byte) ([]byte, error) {
+	level := c.Level
+
+	if level == 0 {
+		level = brotli.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+
+	bw := brotli.NewWriterLevel(&buf, level)
+
+	_, err := bw.Write(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = bw.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
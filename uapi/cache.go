@@ -0,0 +1,137 @@
+package uapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// DefaultCacheKey returns a CacheKeyFunc keying on the method, path and the given query parameters
+//
+// Query parameters not listed are ignored, so they can't be used to bypass or poison the cache
+func DefaultCacheKey(queryParams ...string) func(d RouteData, r *http.Request) string {
+	params := append([]string{}, queryParams...)
+	sort.Strings(params)
+
+	return func(d RouteData, r *http.Request) string {
+		var b strings.Builder
+
+		b.WriteString("uapi:")
+		b.WriteString(r.Method)
+		b.WriteString(":")
+		b.WriteString(r.URL.Path)
+
+		query := r.URL.Query()
+
+		for _, param := range params {
+			values, ok := query[param]
+
+			if !ok {
+				continue
+			}
+
+			b.WriteString("?")
+			b.WriteString(url.QueryEscape(param))
+			b.WriteString("=")
+
+			for i, v := range values {
+				if i > 0 {
+					b.WriteString(",")
+				}
+
+				b.WriteString(url.QueryEscape(v))
+			}
+		}
+
+		return b.String()
+	}
+}
+
+// FromCache returns the response cached under key by a previous response with CacheKey set
+//
+// Returns false if caching is disabled (State.Redis is nil) or nothing is cached. The returned response
+// is served with X-Cache: HIT
+func FromCache(ctx context.Context, key string) (HttpResponse, bool) {
+	if State.Redis == nil {
+		return HttpResponse{}, false
+	}
+
+	bytes, err := State.Redis.Get(ctx, key).Bytes()
+
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			State.Logger.Error("[uapi/FromCache] Failed to get cached response", zap.Error(err), zap.String("key", key))
+		}
+
+		return HttpResponse{}, false
+	}
+
+	return HttpResponse{
+		Bytes:    bytes,
+		CacheKey: key,
+		cached:   true,
+	}, true
+}
+
+// Sets the X-Cache header and stores the body if the response should be cached
+func cacheResponse(ctx context.Context, w http.ResponseWriter, msg HttpResponse, body []byte) {
+	if msg.CacheKey == "" {
+		return
+	}
+
+	if msg.cached {
+		w.Header().Set("X-Cache", "HIT")
+		return
+	}
+
+	w.Header().Set("X-Cache", "MISS")
+
+	// Only cache successful responses
+	if State.Redis == nil || msg.CacheTime <= 0 || (msg.Status != 0 && (msg.Status < 200 || msg.Status > 299)) {
+		return
+	}
+
+	err := State.Redis.Set(ctx, msg.CacheKey, body, msg.CacheTime).Err()
+
+	if err != nil {
+		State.Logger.Error("[uapi/respond] Failed to cache response", zap.Error(err), zap.String("key", msg.CacheKey))
+	}
+}
+
+// ErrNotCached is returned by CacheTTL when nothing is cached under the key
+var ErrNotCached = errors.New("key is not cached")
+
+// CacheNoExpiry is returned by CacheTTL for keys that never expire
+const CacheNoExpiry time.Duration = -1
+
+// CacheTTL returns how long until the response cached under key expires
+//
+// Returns ErrNotCached if nothing is cached under the key and CacheNoExpiry if the key never expires
+func CacheTTL(ctx context.Context, key string) (time.Duration, error) {
+	if State.Redis == nil {
+		return 0, errors.New("caching is disabled as State.Redis is nil")
+	}
+
+	ttl, err := State.Redis.TTL(ctx, key).Result()
+
+	if err != nil {
+		return 0, err
+	}
+
+	// Depending on the redis client version, these are returned either as is or in seconds
+	switch ttl {
+	case -2, -2 * time.Second:
+		return 0, ErrNotCached
+	case -1, -1 * time.Second:
+		return CacheNoExpiry, nil
+	}
+
+	return ttl, nil
+}
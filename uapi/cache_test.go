@@ -0,0 +1,142 @@
+package uapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestResponseCacheHeader(t *testing.T) {
+	calls := 0
+
+	r := docsRoute("/users", "list_users", testUser{})
+	r.CacheKeyFunc = DefaultCacheKey("page")
+	r.Handler = func(d RouteData, r *http.Request) HttpResponse {
+		if resp, ok := FromCache(d.Context, d.CacheKey); ok {
+			return resp
+		}
+
+		calls++
+
+		return HttpResponse{
+			Json:      testUser{ID: "1", Username: "octocat"},
+			CacheTime: time.Minute,
+		}
+	}
+
+	mux := serveRoutes(t, r)
+
+	mr := miniredis.RunT(t)
+	State.Redis = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	t.Cleanup(func() { State.Redis.Close() })
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	first := get("/users?page=1")
+
+	if got := first.Header().Get("X-Cache"); got != "MISS" {
+		t.Fatalf("first request got X-Cache %q, want MISS", got)
+	}
+
+	second := get("/users?page=1&utm=ignored")
+
+	if got := second.Header().Get("X-Cache"); got != "HIT" {
+		t.Fatalf("second request got X-Cache %q, want HIT", got)
+	}
+
+	if second.Body.String() != first.Body.String() {
+		t.Fatalf("cached body %q does not match %q", second.Body.String(), first.Body.String())
+	}
+
+	if calls != 1 {
+		t.Fatalf("handler built the response %d times, want 1", calls)
+	}
+
+	// A different page is keyed separately
+	if got := get("/users?page=2").Header().Get("X-Cache"); got != "MISS" {
+		t.Fatalf("other page got X-Cache %q, want MISS", got)
+	}
+
+	if ttl, err := CacheTTL(context.Background(), "uapi:GET:/users?page=1"); err != nil || ttl != time.Minute {
+		t.Fatalf("got TTL %s, %v, want 1m", ttl, err)
+	}
+}
+
+func TestDefaultCacheKey(t *testing.T) {
+	keyFunc := DefaultCacheKey("page", "sort")
+
+	key := func(method, target string) string {
+		return keyFunc(RouteData{}, httptest.NewRequest(method, target, nil))
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		same bool
+	}{
+		{"identical query", "/bots?page=1&sort=votes", "/bots?page=1&sort=votes", true},
+		{"query order", "/bots?page=1&sort=votes", "/bots?sort=votes&page=1", true},
+		{"unlisted params ignored", "/bots?page=1", "/bots?page=1&cachebust=123", true},
+		{"different value", "/bots?page=1", "/bots?page=2", false},
+		{"missing param", "/bots?page=1", "/bots", false},
+		{"different param", "/bots?page=1", "/bots?sort=1", false},
+		{"different path", "/bots?page=1", "/users?page=1", false},
+		{"repeated values", "/bots?page=1&page=2", "/bots?page=1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := key(http.MethodGet, tt.a), key(http.MethodGet, tt.b)
+
+			if (a == b) != tt.same {
+				t.Fatalf("%s => %q, %s => %q, want same=%v", tt.a, a, tt.b, b, tt.same)
+			}
+		})
+	}
+
+	if key(http.MethodGet, "/bots") == key(http.MethodHead, "/bots") {
+		t.Fatal("different methods share a cache key")
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	setupTestState(t)
+
+	ctx := context.Background()
+
+	if _, err := CacheTTL(ctx, "any"); err == nil {
+		t.Fatal("expected an error without State.Redis")
+	}
+
+	mr := miniredis.RunT(t)
+	State.Redis = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	t.Cleanup(func() { State.Redis.Close() })
+
+	mr.Set("expiring", "{}")
+	mr.SetTTL("expiring", 90*time.Second)
+	mr.Set("forever", "{}")
+
+	if ttl, err := CacheTTL(ctx, "expiring"); err != nil || ttl != 90*time.Second {
+		t.Fatalf("existing key: got %s, %v, want 1m30s", ttl, err)
+	}
+
+	if ttl, err := CacheTTL(ctx, "forever"); err != nil || ttl != CacheNoExpiry {
+		t.Fatalf("key without expiry: got %s, %v, want CacheNoExpiry", ttl, err)
+	}
+
+	if _, err := CacheTTL(ctx, "missing"); !errors.Is(err, ErrNotCached) {
+		t.Fatalf("missing key: got %v, want ErrNotCached", err)
+	}
+}
@@ -0,0 +1,104 @@
+package uapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Responses smaller than this are not compressed as the overhead outweighs the savings
+var CompressionMinSize = 1024
+
+// A Compressor compresses response bodies with a HTTP content coding
+type Compressor interface {
+	// The content coding as used in Accept-Encoding/Content-Encoding, e.g. gzip
+	Encoding() string
+
+	// Compresses data
+	Compress(data []byte) ([]byte, error)
+}
+
+// GzipCompressor compresses responses using gzip
+type GzipCompressor struct {
+	// Compression level, defaults to gzip.DefaultCompression
+	Level int
+}
+
+func (g GzipCompressor) Encoding() string {
+	return "gzip"
+}
+
+func (g GzipCompressor) Compress(data []byte) ([]byte, error) {
+	level := g.Level
+
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+
+	gw, err := gzip.NewWriterLevel(&buf, level)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = gw.Write(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = gw.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Returns the first compressor in compressors accepted by the request, or nil if none are
+func negotiateCompressor(req *http.Request, compressors []Compressor) Compressor {
+	if len(compressors) == 0 {
+		return nil
+	}
+
+	acceptEncoding := req.Header.Get("Accept-Encoding")
+
+	if acceptEncoding == "" {
+		return nil
+	}
+
+	accepted := map[string]bool{}
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		// A q value of 0 means the coding is explicitly not acceptable
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			if v, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64); err == nil && v == 0 {
+				accepted[strings.ToLower(coding)] = false
+				continue
+			}
+		}
+
+		accepted[strings.ToLower(coding)] = true
+	}
+
+	for _, c := range compressors {
+		ok, found := accepted[c.Encoding()]
+
+		if !found {
+			ok = accepted["*"]
+		}
+
+		if ok {
+			return c
+		}
+	}
+
+	return nil
+}
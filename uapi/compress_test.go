@@ -0,0 +1,87 @@
+package uapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	andybrotli "github.com/andybalholm/brotli"
+	"github.com/topicbotlist/eureka-port/uapi/brotli"
+)
+
+func TestCompression(t *testing.T) {
+	// Large enough to be compressed
+	user := testUser{ID: "1", Username: strings.Repeat("octocat", CompressionMinSize)}
+
+	r := docsRoute("/big", "big", testUser{})
+	r.Handler = func(d RouteData, r *http.Request) HttpResponse {
+		return HttpResponse{Json: user}
+	}
+
+	mux := serveRoutes(t, r)
+	State.Compressors = []Compressor{brotli.Compressor{}, GzipCompressor{}}
+
+	want, err := State.JSON.Marshal(user)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		encoding       string
+		decompress     func(r io.Reader) (io.Reader, error)
+	}{
+		{"brotli preferred", "gzip, deflate, br", "br", func(r io.Reader) (io.Reader, error) { return andybrotli.NewReader(r), nil }},
+		{"gzip only", "gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"brotli refused", "br;q=0, gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"identity", "identity", "", nil},
+		{"no accept-encoding", "", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/big", nil)
+
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("got Content-Encoding %q, want %q", got, tt.encoding)
+			}
+
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Fatalf("got Vary %q, want Accept-Encoding", got)
+			}
+
+			var body io.Reader = w.Body
+
+			if tt.decompress != nil {
+				body, err = tt.decompress(w.Body)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := io.ReadAll(body)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got, want) {
+				t.Fatalf("decoded body does not match the response (%d bytes, want %d)", len(got), len(want))
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package uapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Returns a route limited to maxConcurrency handlers, which block until release is closed
+func blockingRoute(t *testing.T, maxConcurrency int, wait time.Duration) (mux *chi.Mux, started, release chan struct{}) {
+	t.Helper()
+
+	started = make(chan struct{}, 16)
+	release = make(chan struct{})
+
+	r := docsRoute("/expensive", "expensive", testUser{})
+	r.MaxConcurrency = maxConcurrency
+	r.MaxConcurrencyWait = wait
+	r.Handler = func(d RouteData, r *http.Request) HttpResponse {
+		started <- struct{}{}
+		<-release
+		return HttpResponse{Json: testUser{ID: "1"}}
+	}
+
+	return serveRoutes(t, r), started, release
+}
+
+// Serves a request in the background, sending the response once done
+func serveAsync(mux *chi.Mux) chan *httptest.ResponseRecorder {
+	done := make(chan *httptest.ResponseRecorder, 1)
+
+	go func() {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/expensive", nil))
+		done <- w
+	}()
+
+	return done
+}
+
+func TestMaxConcurrency(t *testing.T) {
+	mux, started, release := blockingRoute(t, 2, 0)
+
+	var running []chan *httptest.ResponseRecorder
+
+	for i := 0; i < 2; i++ {
+		running = append(running, serveAsync(mux))
+		<-started
+	}
+
+	// The cap is reached, the excess are rejected straight away
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/expensive", nil))
+
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+			t.Fatalf("excess request got status %d and Retry-After %q, want 503 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+		}
+	}
+
+	close(release)
+
+	for _, done := range running {
+		if w := <-done; w.Code != http.StatusOK {
+			t.Fatalf("request within the cap got status %d, want 200", w.Code)
+		}
+	}
+
+	// Slots are given back once the handlers finish
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/expensive", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("request after the handlers finished got status %d, want 200", w.Code)
+	}
+}
+
+func TestMaxConcurrencyWait(t *testing.T) {
+	mux, started, release := blockingRoute(t, 1, time.Minute)
+
+	first := serveAsync(mux)
+	<-started
+
+	// Queues for the slot instead of being rejected
+	queued := serveAsync(mux)
+
+	select {
+	case w := <-queued:
+		t.Fatalf("queued request finished with status %d while the slot was taken", w.Code)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+
+	for _, done := range []chan *httptest.ResponseRecorder{first, queued} {
+		if w := <-done; w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", w.Code)
+		}
+	}
+}
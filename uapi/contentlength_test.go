@@ -0,0 +1,63 @@
+package uapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestContentLength(t *testing.T) {
+	tests := []struct {
+		name           string
+		resp           HttpResponse
+		acceptEncoding string
+	}{
+		{"json", HttpResponse{Json: testUser{ID: "1", Username: "octocat"}}, ""},
+		{"bytes", HttpResponse{Bytes: []byte("raw bytes")}, ""},
+		{"data", HttpResponse{Data: "some data"}, ""},
+		{"redirect", HttpResponse{Redirect: "https://example.com/"}, ""},
+		{"empty", HttpResponse{Status: http.StatusOK}, ""},
+		{"compressed", HttpResponse{Data: strings.Repeat("octocat", CompressionMinSize)}, "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := docsRoute("/length", "length", testUser{})
+			r.Handler = func(d RouteData, r *http.Request) HttpResponse { return tt.resp }
+
+			mux := serveRoutes(t, r)
+			State.Compressors = []Compressor{GzipCompressor{}}
+
+			req := httptest.NewRequest(http.MethodGet, "/length", nil)
+
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, req)
+
+			if got, want := w.Header().Get("Content-Length"), strconv.Itoa(w.Body.Len()); got != want {
+				t.Fatalf("got Content-Length %q, want %s", got, want)
+			}
+
+			if tt.acceptEncoding != "" && w.Header().Get("Content-Encoding") != tt.acceptEncoding {
+				t.Fatal("response was not compressed, so the compressed length was not checked")
+			}
+		})
+	}
+}
+
+func TestContentLengthNoBody(t *testing.T) {
+	r := docsRoute("/length", "length", testUser{})
+	r.Handler = func(d RouteData, r *http.Request) HttpResponse { return HttpResponse{Status: http.StatusNoContent} }
+
+	w := httptest.NewRecorder()
+	serveRoutes(t, r).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/length", nil))
+
+	if got := w.Header().Get("Content-Length"); got != "" {
+		t.Fatalf("got Content-Length %q on a 204", got)
+	}
+}
@@ -0,0 +1,66 @@
+package uapi
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How long a CSRF token is valid for after being issued
+var CSRFTokenExpiry = 12 * time.Hour
+
+func csrfSignature(secret, sessionID, ts string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(sessionID + "." + ts))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CSRFToken returns a CSRF token bound to the session, valid for CSRFTokenExpiry
+func CSRFToken(secret, sessionID string) string {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	return ts + "." + csrfSignature(secret, sessionID, ts)
+}
+
+// Returns whether token is a valid, unexpired CSRF token for the session
+func validCSRFToken(secret, sessionID, token string) bool {
+	ts, sig, ok := strings.Cut(token, ".")
+
+	if !ok {
+		return false
+	}
+
+	issued, err := strconv.ParseInt(ts, 10, 64)
+
+	if err != nil {
+		return false
+	}
+
+	if time.Since(time.Unix(issued, 0)) > CSRFTokenExpiry {
+		return false
+	}
+
+	return hmac.Equal([]byte(sig), []byte(csrfSignature(secret, sessionID, ts)))
+}
+
+// CheckCSRF verifies the X-CSRF-Token header of state-changing requests (anything other than GET, HEAD and OPTIONS)
+//
+// Returns a 403 response if the token is missing, invalid or expired
+func CheckCSRF(secret, sessionID string, r *http.Request) (HttpResponse, bool) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return HttpResponse{}, true
+	}
+
+	if !validCSRFToken(secret, sessionID, r.Header.Get("X-CSRF-Token")) {
+		return HttpResponse{
+			Status: http.StatusForbidden,
+			Json:   State.DefaultResponder.New("Invalid or expired CSRF token", nil),
+		}, false
+	}
+
+	return HttpResponse{}, true
+}
@@ -0,0 +1,66 @@
+package uapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCheckCSRF(t *testing.T) {
+	setupTestState(t)
+
+	const secret = "csrf-secret"
+	const session = "session-1"
+
+	valid := CSRFToken(secret, session)
+
+	expiredTs := strconv.FormatInt(time.Now().Add(-CSRFTokenExpiry-time.Minute).Unix(), 10)
+	expired := expiredTs + "." + csrfSignature(secret, session, expiredTs)
+
+	// Change the last character of the signature
+	last := "0"
+
+	if valid[len(valid)-1] == '0' {
+		last = "1"
+	}
+
+	tampered := valid[:len(valid)-1] + last
+
+	// Reuses a valid signature with a later timestamp
+	ts, _ := strconv.ParseInt(valid[:len(expiredTs)], 10, 64)
+	extended := strconv.FormatInt(ts+3600, 10) + valid[len(expiredTs):]
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		ok     bool
+	}{
+		{"valid", http.MethodPost, valid, true},
+		{"tampered", http.MethodPost, tampered, false},
+		{"extended timestamp", http.MethodPost, extended, false},
+		{"expired", http.MethodDelete, expired, false},
+		{"other session", http.MethodPost, CSRFToken(secret, "session-2"), false},
+		{"missing", http.MethodPatch, "", false},
+		{"safe method", http.MethodGet, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			r.Header.Set("X-CSRF-Token", tt.token)
+
+			resp, ok := CheckCSRF(secret, session, r)
+
+			if ok != tt.ok {
+				t.Fatalf("got ok=%v, want %v", ok, tt.ok)
+			}
+
+			if !ok && resp.Status != http.StatusForbidden {
+				t.Fatalf("got status %d, want 403", resp.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package uapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxJSONDepth(t *testing.T) {
+	decoders := map[string]func(r *http.Request, dst any) (HttpResponse, bool){
+		"MarshalReq":   MarshalReq,
+		"DecodeStream": DecodeStream,
+	}
+
+	tests := []struct {
+		name string
+		body string
+		ok   bool
+	}{
+		{"at the limit", `{"a": [{"b": 1}]}`, true},
+		{"brackets in strings", `{"a": "[[[[{{{{\"]]"}`, true},
+		{"over the limit", `{"a": [{"b": [1]}]}`, false},
+		{"deeply nested array", strings.Repeat("[", 10000) + strings.Repeat("]", 10000), false},
+	}
+
+	for name, decode := range decoders {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				setupTestState(t)
+				State.MaxJSONDepth = 3
+
+				var dst any
+
+				resp, ok := decode(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)), &dst)
+
+				if ok != tt.ok {
+					t.Fatalf("got ok=%v (%+v), want %v", ok, resp, tt.ok)
+				}
+
+				if tt.ok {
+					return
+				}
+
+				if resp.Status != http.StatusBadRequest {
+					t.Fatalf("got status %d, want 400", resp.Status)
+				}
+
+				ctx, _ := resp.Json.(map[string]any)["context"].(map[string]string)
+
+				if ctx["max_depth"] != "3" {
+					t.Fatalf("unexpected error response %+v", resp.Json)
+				}
+			})
+		}
+	}
+}
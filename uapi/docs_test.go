@@ -0,0 +1,220 @@
+package uapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	docs "github.com/topicbotlist/eureka-port/doclib"
+)
+
+type testError struct {
+	Message string `json:"message" description:"The error message"`
+}
+
+type testUser struct {
+	ID       string   `json:"id" description:"The user's ID"`
+	Username string   `json:"username" description:"The user's username"`
+	Bot      bool     `json:"bot" description:"Whether the user is a bot"`
+	Roles    []string `json:"roles" description:"The user's roles"`
+}
+
+// Resets the docs so routes can be registered by tests
+func setupTestDocs(t *testing.T) {
+	t.Helper()
+
+	setupTestState(t)
+
+	docs.DocsSetupData = &docs.SetupData{
+		URL:         "http://localhost",
+		ErrorStruct: testError{},
+	}
+
+	docs.Setup()
+}
+
+// Returns the GET operation registered for pattern
+func getOperation(t *testing.T, pattern string) *docs.Operation {
+	t.Helper()
+
+	path, ok := docs.GetSchema().Paths.Get(pattern)
+
+	if !ok || path.Get == nil {
+		t.Fatalf("no GET operation registered for %s", pattern)
+	}
+
+	return path.Get
+}
+
+// Registers the routes on a new router, authorizing every request
+func serveRoutes(t *testing.T, routes ...Route) *chi.Mux {
+	t.Helper()
+
+	setupTestDocs(t)
+
+	State.Authorize = func(r Route, req *http.Request) (AuthData, HttpResponse, bool) {
+		return AuthData{}, HttpResponse{}, true
+	}
+
+	mux := chi.NewRouter()
+
+	for _, r := range routes {
+		r.Route(mux)
+	}
+
+	return mux
+}
+
+func docsRoute(pattern, opId string, resp any) Route {
+	return Route{
+		Method:  GET,
+		Pattern: pattern,
+		OpId:    opId,
+		Handler: func(d RouteData, r *http.Request) HttpResponse { return HttpResponse{} },
+		Docs: func() *docs.Doc {
+			return &docs.Doc{Summary: "Test", Resp: resp}
+		},
+	}
+}
+
+func TestRouteExamples(t *testing.T) {
+	setupTestDocs(t)
+
+	r := docsRoute("/users", "get_users", testUser{})
+	r.Examples = map[int]any{
+		http.StatusOK:       testUser{ID: "1", Username: "octocat"},
+		http.StatusNotFound: testError{Message: "user not found"},
+	}
+
+	r.Route(chi.NewRouter())
+
+	op := getOperation(t, "/users")
+
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"200", `{"id":"1","username":"octocat","bot":false,"roles":null}`},
+		{"404", `{"message":"user not found"}`},
+	}
+
+	for _, tt := range tests {
+		resp, ok := op.Responses[tt.status]
+
+		if !ok {
+			t.Fatalf("no %s response in docs", tt.status)
+		}
+
+		example, ok := resp.Content["application/json"].Example.(json.RawMessage)
+
+		if !ok {
+			t.Fatalf("%s example is %T, want json.RawMessage", tt.status, resp.Content["application/json"].Example)
+		}
+
+		if string(example) != tt.want {
+			t.Fatalf("%s example is %s, want %s", tt.status, example, tt.want)
+		}
+	}
+
+	if got := op.Responses["404"].Content["application/json"].Schema.Ref; got != "#/components/schemas/uapi.testError" {
+		t.Fatalf("404 response references %s, want the error schema", got)
+	}
+}
+
+func TestRouteRespType(t *testing.T) {
+	setupTestDocs(t)
+
+	// RespType takes precedence over the response in the docs
+	r := docsRoute("/users/@me", "get_current_user", testError{})
+	r.RespType = testUser{}
+
+	r.Route(chi.NewRouter())
+
+	op := getOperation(t, "/users/@me")
+
+	if got := op.Responses["200"].Content["application/json"].Schema.Ref; got != "#/components/schemas/uapi.testUser" {
+		t.Fatalf("200 response references %s, want the RespType schema", got)
+	}
+
+	schemaRef, ok := docs.GetSchema().Components.Schemas["uapi.testUser"].(*openapi3.SchemaRef)
+
+	if !ok {
+		t.Fatalf("no schema generated for RespType")
+	}
+
+	want := map[string]struct {
+		typ         string
+		description string
+	}{
+		"id":       {"string", "The user's ID"},
+		"username": {"string", "The user's username"},
+		"bot":      {"boolean", "Whether the user is a bot"},
+		"roles":    {"array", "The user's roles"},
+	}
+
+	props := schemaRef.Value.Properties
+
+	if len(props) != len(want) {
+		t.Fatalf("got %d properties, want %d", len(props), len(want))
+	}
+
+	for name, w := range want {
+		prop, ok := props[name]
+
+		if !ok {
+			t.Fatalf("schema is missing property %s", name)
+		}
+
+		if prop.Value.Type != w.typ {
+			t.Errorf("%s has type %s, want %s", name, prop.Value.Type, w.typ)
+		}
+
+		if prop.Value.Description != w.description {
+			t.Errorf("%s has description %q, want %q", name, prop.Value.Description, w.description)
+		}
+	}
+}
+
+func TestRouteSecurity(t *testing.T) {
+	tests := []struct {
+		name     string
+		optional bool
+		want     []map[string][]string
+	}{
+		{"required", false, []map[string][]string{{"User": {}}, {"Bot": {}}}},
+		{"optional", true, []map[string][]string{{"User": {}}, {"Bot": {}}, {}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestDocs(t)
+			State.AuthTypeMap["bot"] = "Bot"
+
+			r := docsRoute("/bots/@me", "get_current_bot", testUser{})
+			r.Auth = []AuthType{{Type: "user"}, {Type: "bot"}}
+			r.AuthOptional = tt.optional
+
+			r.Route(chi.NewRouter())
+
+			got := getOperation(t, "/bots/@me").Security
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got security %v, want %v", got, tt.want)
+			}
+
+			for i, req := range tt.want {
+				if len(got[i]) != len(req) {
+					t.Fatalf("requirement %d is %v, want %v", i, got[i], req)
+				}
+
+				for scheme := range req {
+					if _, ok := got[i][scheme]; !ok {
+						t.Fatalf("requirement %d is %v, want %v", i, got[i], req)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package uapi
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// The Retry-After sent to clients while draining
+var DrainRetryAfter = 5 * time.Second
+
+var draining atomic.Bool
+
+// SetDraining toggles draining mode, used during graceful shutdown
+//
+// While draining, new requests are rejected with a 503 and a Retry-After header, while
+// requests that are already being handled run to completion
+func SetDraining(d bool) {
+	draining.Store(d)
+}
+
+// Draining returns whether draining mode is enabled
+func Draining() bool {
+	return draining.Load()
+}
+
+func drainingResponse() HttpResponse {
+	msg := State.Constants.ServiceUnavailable
+
+	if msg == "" {
+		msg = "Service is shutting down, please try again later"
+	}
+
+	return HttpResponse{
+		Status: http.StatusServiceUnavailable,
+		Json:   State.DefaultResponder.New(msg, nil),
+		Headers: map[string]string{
+			"Retry-After": strconv.Itoa(int(DrainRetryAfter.Seconds())),
+		},
+	}
+}
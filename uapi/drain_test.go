@@ -0,0 +1,62 @@
+package uapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestDraining(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	r := docsRoute("/slow", "slow", testUser{})
+	r.Handler = func(d RouteData, r *http.Request) HttpResponse {
+		started <- struct{}{}
+		<-release
+		return HttpResponse{Json: testUser{ID: "1"}}
+	}
+
+	mux := serveRoutes(t, r)
+
+	t.Cleanup(func() { SetDraining(false) })
+
+	inFlight := make(chan *httptest.ResponseRecorder)
+
+	go func() {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		inFlight <- w
+	}()
+
+	<-started
+	SetDraining(true)
+
+	// New requests are turned away while the first is still running
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("new request while draining got status %d, want 503", w.Code)
+	}
+
+	if got, want := w.Header().Get("Retry-After"), strconv.Itoa(int(DrainRetryAfter.Seconds())); got != want {
+		t.Fatalf("got Retry-After %q, want %q", got, want)
+	}
+
+	close(release)
+
+	if w := <-inFlight; w.Code != http.StatusOK {
+		t.Fatalf("in-flight request got status %d, want 200", w.Code)
+	}
+
+	SetDraining(false)
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("request after draining was disabled got status %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package uapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestHeadRequest(t *testing.T) {
+	handler := func(d RouteData, r *http.Request) HttpResponse {
+		return HttpResponse{
+			Json:    testUser{ID: "1", Username: "octocat"},
+			Headers: map[string]string{"X-User-Id": "1"},
+		}
+	}
+
+	get := docsRoute("/users/@me", "get_current_user", testUser{})
+	get.Handler = handler
+
+	head := docsRoute("/users/@me", "head_current_user", testUser{})
+	head.Method = HEAD
+	head.Handler = handler
+
+	mux := serveRoutes(t, get, head)
+
+	getW := httptest.NewRecorder()
+	mux.ServeHTTP(getW, httptest.NewRequest(http.MethodGet, "/users/@me", nil))
+
+	headW := httptest.NewRecorder()
+	mux.ServeHTTP(headW, httptest.NewRequest(http.MethodHead, "/users/@me", nil))
+
+	if headW.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", headW.Code)
+	}
+
+	if headW.Body.Len() != 0 {
+		t.Fatalf("HEAD response has a %d byte body: %q", headW.Body.Len(), headW.Body.String())
+	}
+
+	if headW.Header().Get("X-User-Id") != "1" {
+		t.Fatal("HEAD response is missing the handler's headers")
+	}
+
+	// Content-Length is that of the GET response
+	if got, want := headW.Header().Get("Content-Length"), strconv.Itoa(getW.Body.Len()); got != want {
+		t.Fatalf("got Content-Length %q, want %s", got, want)
+	}
+}
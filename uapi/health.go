@@ -0,0 +1,96 @@
+package uapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	docs "github.com/topicbotlist/eureka-port/doclib"
+)
+
+// The maximum time a single health check may take before it is considered failed
+var HealthCheckTimeout = 5 * time.Second
+
+type HealthResponse struct {
+	Healthy bool              `json:"healthy" description:"Whether all health checks passed"`
+	Checks  map[string]string `json:"checks" description:"The result of each check, either ok or the error returned by the check"`
+}
+
+// HealthRoute returns a GET /health route that runs each check concurrently with a timeout of HealthCheckTimeout
+//
+// Returns 200 if all checks pass and 503 otherwise. The returned route can be modified (e.g. to
+// change the pattern) before calling Route()
+func HealthRoute(checks map[string]func(ctx context.Context) error) Route {
+	return Route{
+		Method:  GET,
+		Pattern: "/health",
+		OpId:    "health",
+		Docs: func() *docs.Doc {
+			return &docs.Doc{
+				Summary:     "Health Check",
+				Description: "Returns the health of the service and its dependencies. Returns 503 if any check fails",
+				Resp:        HealthResponse{},
+			}
+		},
+		Handler: func(d RouteData, r *http.Request) HttpResponse {
+			resp := HealthResponse{
+				Healthy: true,
+				Checks:  make(map[string]string, len(checks)),
+			}
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+
+			for name, check := range checks {
+				wg.Add(1)
+
+				go func(name string, check func(ctx context.Context) error) {
+					defer wg.Done()
+
+					ctx, cancel := context.WithTimeout(d.Context, HealthCheckTimeout)
+					defer cancel()
+
+					// Don't rely on the check honoring the context
+					done := make(chan error, 1)
+
+					go func() {
+						done <- check(ctx)
+					}()
+
+					var err error
+
+					select {
+					case err = <-done:
+					case <-ctx.Done():
+						err = ctx.Err()
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+
+					if err != nil {
+						resp.Healthy = false
+						resp.Checks[name] = err.Error()
+						return
+					}
+
+					resp.Checks[name] = "ok"
+				}(name, check)
+			}
+
+			wg.Wait()
+
+			if !resp.Healthy {
+				return HttpResponse{
+					Status: http.StatusServiceUnavailable,
+					Json:   resp,
+				}
+			}
+
+			return HttpResponse{
+				Json: resp,
+			}
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package uapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Registers a health route with the given checks and returns the response to GET /health
+func getHealth(t *testing.T, checks map[string]func(ctx context.Context) error) (int, HealthResponse) {
+	t.Helper()
+
+	mux := serveRoutes(t, HealthRoute(checks))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var resp HealthResponse
+
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode health response %q: %s", w.Body.String(), err)
+	}
+
+	return w.Code, resp
+}
+
+func TestHealthRouteHealthy(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+
+	status, resp := getHealth(t, map[string]func(ctx context.Context) error{
+		"postgres": ok,
+		"redis":    ok,
+	})
+
+	if status != http.StatusOK {
+		t.Fatalf("got status %d, want 200", status)
+	}
+
+	if !resp.Healthy || resp.Checks["postgres"] != "ok" || resp.Checks["redis"] != "ok" {
+		t.Fatalf("unexpected health response: %+v", resp)
+	}
+}
+
+func TestHealthRouteFailingDependency(t *testing.T) {
+	status, resp := getHealth(t, map[string]func(ctx context.Context) error{
+		"postgres": func(ctx context.Context) error { return nil },
+		"redis":    func(ctx context.Context) error { return errors.New("connection refused") },
+	})
+
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("got status %d, want 503", status)
+	}
+
+	if resp.Healthy {
+		t.Fatal("expected the service to be reported unhealthy")
+	}
+
+	if resp.Checks["postgres"] != "ok" || resp.Checks["redis"] != "connection refused" {
+		t.Fatalf("unexpected check results: %+v", resp.Checks)
+	}
+}
+
+func TestHealthRouteTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { HealthCheckTimeout = timeout }(HealthCheckTimeout)
+	HealthCheckTimeout = 10 * time.Millisecond
+
+	block := make(chan struct{})
+	defer close(block)
+
+	status, resp := getHealth(t, map[string]func(ctx context.Context) error{
+		// Ignores the context, the check must still be cut off
+		"hung": func(ctx context.Context) error { <-block; return nil },
+	})
+
+	if status != http.StatusServiceUnavailable {
+		t.Fatalf("got status %d, want 503", status)
+	}
+
+	if resp.Checks["hung"] != context.DeadlineExceeded.Error() {
+		t.Fatalf("got %q for the hung check, want %q", resp.Checks["hung"], context.DeadlineExceeded.Error())
+	}
+}
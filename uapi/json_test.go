@@ -0,0 +1,48 @@
+package uapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+func TestCustomJSONConfig(t *testing.T) {
+	r := docsRoute("/stats", "get_stats", testUser{})
+	r.Handler = func(d RouteData, r *http.Request) HttpResponse {
+		return HttpResponse{Json: map[string]int{"zeta": 1, "alpha": 2, "mu": 3, "beta": 4, "omega": 5}}
+	}
+
+	mux := serveRoutes(t, r)
+	State.JSON = jsoniter.Config{SortMapKeys: true}.Froze()
+
+	for i := 0; i < 10; i++ {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+		if got, want := w.Body.String(), `{"alpha":2,"beta":4,"mu":3,"omega":5,"zeta":1}`; got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestCustomJSONConfigDecode(t *testing.T) {
+	setupTestState(t)
+	State.JSON = jsoniter.Config{UseNumber: true}.Froze()
+
+	var dst map[string]any
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id": 1234567890123456789}`))
+
+	if resp, ok := MarshalReq(req, &dst); !ok {
+		t.Fatalf("failed to decode request: %+v", resp)
+	}
+
+	// Without UseNumber this would be a float64, losing precision
+	if n, ok := dst["id"].(json.Number); !ok || n.String() != "1234567890123456789" {
+		t.Fatalf("got %T %v, want a json.Number", dst["id"], dst["id"])
+	}
+}
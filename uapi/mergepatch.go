@@ -0,0 +1,127 @@
+package uapi
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"reflect"
+
+	"go.uber.org/zap"
+)
+
+// Applies a RFC 7386 merge patch to target
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+
+	if !ok {
+		targetObj = map[string]any{}
+	}
+
+	for k, v := range patchObj {
+		if v == nil {
+			delete(targetObj, k)
+			continue
+		}
+
+		targetObj[k] = mergePatch(targetObj[k], v)
+	}
+
+	return targetObj
+}
+
+// Decodes data keeping numbers as json.Number, so integers beyond float64 precision survive the merge
+func unmarshalNumber(data []byte, v any) error {
+	dec := State.JSON.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// MarshalMergePatch applies the request body as a JSON Merge Patch (RFC 7386) to current and stores the result in dst
+//
+// Fields absent from the patch keep their value in current, and fields set to null are cleared.
+// current is not modified, and dst may point to current to patch it in place
+func MarshalMergePatch(r *http.Request, current, dst any) (resp HttpResponse, ok bool) {
+	defer r.Body.Close()
+
+	bodyBytes, err := io.ReadAll(limitBody(r))
+
+	if err != nil {
+		if resp, ok := bodyTooLarge(err); ok {
+			return resp, false
+		}
+
+		State.Logger.Error("[uapi/MarshalMergePatch] Failed to read body", zap.Error(err), zap.Int("size", len(bodyBytes)))
+		return DefaultResponse(http.StatusInternalServerError), false
+	}
+
+	if len(bodyBytes) == 0 {
+		return HttpResponse{
+			Status: http.StatusBadRequest,
+			Json:   State.Constants.BodyRequired,
+		}, false
+	}
+
+	if resp, ok := checkJSONDepth(bodyBytes); !ok {
+		return resp, false
+	}
+
+	var patch any
+
+	err = unmarshalNumber(bodyBytes, &patch)
+
+	if err != nil {
+		return HttpResponse{
+			Status: http.StatusBadRequest,
+			Json: State.DefaultResponder.New("Invalid JSON", map[string]string{
+				"error": err.Error(),
+			}),
+		}, false
+	}
+
+	currentBytes, err := State.JSON.Marshal(current)
+
+	if err != nil {
+		State.Logger.Error("[uapi/MarshalMergePatch] Failed to marshal current object", zap.Error(err))
+		return DefaultResponse(http.StatusInternalServerError), false
+	}
+
+	var target any
+
+	err = unmarshalNumber(currentBytes, &target)
+
+	if err != nil {
+		State.Logger.Error("[uapi/MarshalMergePatch] Failed to unmarshal current object", zap.Error(err))
+		return DefaultResponse(http.StatusInternalServerError), false
+	}
+
+	mergedBytes, err := State.JSON.Marshal(mergePatch(target, patch))
+
+	if err != nil {
+		State.Logger.Error("[uapi/MarshalMergePatch] Failed to marshal patched object", zap.Error(err))
+		return DefaultResponse(http.StatusInternalServerError), false
+	}
+
+	// Zero dst first so cleared fields don't keep their old value when dst is current
+	if v := reflect.ValueOf(dst); v.Kind() == reflect.Pointer && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+
+	err = State.JSON.Unmarshal(mergedBytes, dst)
+
+	if err != nil {
+		return HttpResponse{
+			Status: http.StatusBadRequest,
+			Json: State.DefaultResponder.New("Patch does not match the expected type", map[string]string{
+				"error": err.Error(),
+			}),
+		}, false
+	}
+
+	return HttpResponse{}, true
+}
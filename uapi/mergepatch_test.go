@@ -0,0 +1,168 @@
+package uapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type patchTarget struct {
+	Name  string   `json:"name"`
+	Bio   *string  `json:"bio"`
+	Tags  []string `json:"tags"`
+	Count int      `json:"count"`
+}
+
+func TestMarshalMergePatch(t *testing.T) {
+	setupTestState(t)
+
+	bio := "hello"
+	current := patchTarget{Name: "a", Bio: &bio, Tags: []string{"x"}, Count: 1}
+
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"name": "b", "bio": null, "count": 2}`))
+
+	var dst patchTarget
+
+	if resp, ok := MarshalMergePatch(r, current, &dst); !ok {
+		t.Fatalf("unexpected failure: %+v", resp)
+	}
+
+	if dst.Name != "b" || dst.Bio != nil || dst.Count != 2 || len(dst.Tags) != 1 || dst.Tags[0] != "x" {
+		t.Fatalf("unexpected result %+v", dst)
+	}
+
+	if current.Name != "a" || current.Bio == nil {
+		t.Fatal("current was modified")
+	}
+}
+
+func TestMarshalMergePatchMaxBodyBytes(t *testing.T) {
+	setupTestState(t)
+	State.MaxBodyBytes = 16
+
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"name": "`+strings.Repeat("a", 32)+`"}`))
+
+	var dst patchTarget
+
+	resp, ok := MarshalMergePatch(r, patchTarget{}, &dst)
+
+	if ok {
+		t.Fatal("oversized body was accepted")
+	}
+
+	if resp.Status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got status %d, want 413", resp.Status)
+	}
+
+	r = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"name": "b"}`))
+
+	if resp, ok := MarshalMergePatch(r, patchTarget{}, &dst); !ok {
+		t.Fatalf("body within the limit was rejected: %+v", resp)
+	}
+}
+
+// The examples from RFC 7386 appendix A
+func TestMergePatchRFCExamples(t *testing.T) {
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	unmarshal := func(s string) any {
+		var v any
+
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+
+		return v
+	}
+
+	for _, tt := range tests {
+		got := mergePatch(unmarshal(tt.target), unmarshal(tt.patch))
+
+		if !reflect.DeepEqual(got, unmarshal(tt.want)) {
+			t.Errorf("patching %s with %s: got %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalMergePatchTypeMismatch(t *testing.T) {
+	setupTestState(t)
+
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"count": "many"}`))
+
+	var dst patchTarget
+
+	if resp, ok := MarshalMergePatch(r, patchTarget{}, &dst); ok || resp.Status != http.StatusBadRequest {
+		t.Fatalf("got %+v, want a 400 for a patch of the wrong type", resp)
+	}
+}
+
+func TestMarshalMergePatchLargeIntegers(t *testing.T) {
+	setupTestState(t)
+
+	type target struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+
+	current := target{ID: 1<<62 + 1, Name: "a"}
+
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"name": "b"}`))
+
+	var dst target
+
+	if resp, ok := MarshalMergePatch(r, current, &dst); !ok {
+		t.Fatalf("unexpected failure: %+v", resp)
+	}
+
+	if dst.ID != current.ID || dst.Name != "b" {
+		t.Fatalf("got %+v, want id %d and name b", dst, current.ID)
+	}
+
+	r = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"id": 9007199254740993}`))
+
+	if resp, ok := MarshalMergePatch(r, current, &dst); !ok {
+		t.Fatalf("unexpected failure: %+v", resp)
+	}
+
+	if dst.ID != 9007199254740993 {
+		t.Fatalf("got id %d, want 9007199254740993", dst.ID)
+	}
+}
+
+func TestMarshalMergePatchInPlace(t *testing.T) {
+	setupTestState(t)
+
+	bio := "hello"
+	obj := patchTarget{Name: "a", Bio: &bio, Tags: []string{"x"}}
+
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"bio": null}`))
+
+	if resp, ok := MarshalMergePatch(r, obj, &obj); !ok {
+		t.Fatalf("unexpected failure: %+v", resp)
+	}
+
+	if obj.Bio != nil || obj.Name != "a" || len(obj.Tags) != 1 {
+		t.Fatalf("unexpected result %+v", obj)
+	}
+}
@@ -0,0 +1,52 @@
+package uapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	docs "github.com/topicbotlist/eureka-port/doclib"
+)
+
+func TestPathParams(t *testing.T) {
+	var got map[string]string
+
+	r := Route{
+		Method:  GET,
+		Pattern: "/teams/{team_id}/members/{user_id}/roles/{role}",
+		OpId:    "get_member_role",
+		Handler: func(d RouteData, r *http.Request) HttpResponse {
+			got = d.PathParams
+			return HttpResponse{}
+		},
+		Docs: func() *docs.Doc {
+			return &docs.Doc{
+				Summary: "Test",
+				Params: []docs.Parameter{
+					{Name: "team_id", In: "path", Description: "Test", Schema: stringSchema},
+					{Name: "user_id", In: "path", Description: "Test", Schema: stringSchema},
+					{Name: "role", In: "path", Description: "Test", Schema: stringSchema},
+				},
+			}
+		},
+	}
+
+	w := httptest.NewRecorder()
+	serveRoutes(t, r).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teams/t1/members/u%202/roles/admin", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d", w.Code)
+	}
+
+	want := map[string]string{"team_id": "t1", "user_id": "u 2", "role": "admin"}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
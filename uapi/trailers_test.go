@@ -0,0 +1,60 @@
+package uapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseTrailers(t *testing.T) {
+	r := docsRoute("/export", "export", testUser{})
+	r.Handler = func(d RouteData, r *http.Request) HttpResponse {
+		return HttpResponse{
+			Json:     testUser{ID: "1", Username: "octocat"},
+			Trailers: map[string]string{"X-Checksum": "abc123"},
+		}
+	}
+
+	srv := httptest.NewServer(serveRoutes(t, r))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/export")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+		t.Fatalf("got transfer encoding %v, want chunked", resp.TransferEncoding)
+	}
+
+	// Trailers are only filled in once the body has been read
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := resp.Trailer.Get("X-Checksum"); got != "abc123" {
+		t.Fatalf("got trailer %q, want abc123", got)
+	}
+}
+
+func TestResponseTrailersUnsupported(t *testing.T) {
+	r := docsRoute("/export", "export", testUser{})
+	r.Handler = func(d RouteData, r *http.Request) HttpResponse {
+		return HttpResponse{
+			Json:     testUser{ID: "1", Username: "octocat"},
+			Trailers: map[string]string{"X-Checksum": "abc123"},
+		}
+	}
+
+	// A recorder has no trailer support on the wire, the response must still be written
+	w := httptest.NewRecorder()
+	serveRoutes(t, r).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export", nil))
+
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("got status %d with body %q", w.Code, w.Body.String())
+	}
+}
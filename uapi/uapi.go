@@ -3,16 +3,21 @@ package uapi
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	docs "github.com/topicbotlist/eureka-port/doclib"
 	"go.uber.org/zap"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
 	"golang.org/x/exp/slices"
 
 	jsoniter "github.com/json-iterator/go"
@@ -39,6 +44,9 @@ type UAPIConstants struct {
 
 	// String returned when the body is required
 	BodyRequired string
+
+	// String returned when the server is draining (503), a default message is used if empty
+	ServiceUnavailable string
 }
 
 type UAPIDefaultResponder interface {
@@ -46,6 +54,21 @@ type UAPIDefaultResponder interface {
 	New(msg string, ctx map[string]string) any
 }
 
+// A single validation failure
+type ValidationError struct {
+	Field   string `json:"field" description:"Path of the field that failed validation, e.g. items[2].name"`
+	Tag     string `json:"tag" description:"The validation rule that failed, e.g. required"`
+	Message string `json:"message" description:"Human readable description of the failure"`
+}
+
+// Default responders can implement this to include every validation failure in validation error responses
+//
+// fields contains every failure message keyed the same way as the context passed to New, which only
+// holds the first failure of each field
+type UAPIValidationResponder interface {
+	NewValidationError(msg string, fields map[string][]string, errs []ValidationError) any
+}
+
 // This struct contains initialization data while loading UAPI (such as the current tag etc.)
 type UAPIInitData struct {
 	// The current tag being loaded
@@ -62,6 +85,21 @@ type UAPIState struct {
 	// Used in cache algo
 	Context context.Context
 
+	// Redis used to cache responses with a CacheKey, caching is disabled if nil
+	Redis redis.UniversalClient
+
+	// The maximum size of request bodies read by MarshalReq, DecodeStream and MarshalMergePatch, unlimited if zero
+	MaxBodyBytes int64
+
+	// The maximum nesting depth of objects and arrays in request bodies read by MarshalReq, DecodeStream
+	// and MarshalMergePatch, unlimited if zero
+	MaxJSONDepth int
+
+	// Key validation errors by struct field name (e.g. Name) instead of by path (e.g. Items[2].Name)
+	//
+	// For consumers relying on the old error context keys
+	LegacyValidationErrors bool
+
 	// Api constants
 	Constants *UAPIConstants
 
@@ -72,6 +110,22 @@ type UAPIState struct {
 
 	// Used to store init data
 	InitData UAPIInitData
+
+	// Compressors used to compress responses, in order of preference
+	//
+	// The first compressor accepted by the client (through Accept-Encoding) is used.
+	// If empty, responses are not compressed
+	Compressors []Compressor
+
+	// Validator used by Validate, defaults to validator.New()
+	//
+	// Set this to share custom validations/tag name functions with the rest of the project
+	Validator *validator.Validate
+
+	// JSON config used to encode responses and decode requests, defaults to Json
+	//
+	// e.g. jsoniter.Config{SortMapKeys: true}.Froze() for deterministic output
+	JSON jsoniter.API
 }
 
 func (s *UAPIState) SetCurrentTag(tag string) {
@@ -83,10 +137,19 @@ func SetupState(s UAPIState) {
 		panic("Constants is nil")
 	}
 
+	if s.Validator == nil {
+		s.Validator = validator.New()
+	}
+
+	if s.JSON == nil {
+		s.JSON = Json
+	}
+
 	State = &s
 }
 
 var (
+	// The default JSON config, see UAPIState.JSON
 	Json = jsoniter.ConfigFastest
 
 	// Stores the UAPI state for UAPI plugins
@@ -130,6 +193,16 @@ func (m Method) String() string {
 	panic("Invalid method")
 }
 
+// Reports whether the method is one of the known methods
+func (m Method) valid() bool {
+	switch m {
+	case GET, POST, PATCH, PUT, DELETE, HEAD:
+		return true
+	}
+
+	return false
+}
+
 type AuthType struct {
 	URLVar       string
 	Type         string
@@ -156,16 +229,69 @@ type Route struct {
 	ExtData      map[string]any
 	AuthOptional bool
 
+	// Example responses keyed by status code, these are added to the docs
+	Examples map[int]any
+
+	// A zero-valued sample of the handlers response type (e.g. types.Bot{})
+	//
+	// If set, this overrides the Resp set by Docs, so the documented response schema is
+	// always derived from what the handler returns
+	RespType any
+
 	// Disables sanity check that ensures all variables are followed by a /
 	//
 	// e.g. /{foo}s/
 	DisablePathSlashCheck bool
+
+	// The maximum number of requests to this route that can be handled at once, unlimited if zero
+	//
+	// Requests over the limit get a 503 with a Retry-After header
+	MaxConcurrency int
+
+	// How long a request may wait for a free slot when MaxConcurrency is reached before being rejected
+	//
+	// If zero, requests over the limit are rejected immediately
+	MaxConcurrencyWait time.Duration
+
+	// If set, derives the cache key of the request, see DefaultCacheKey
+	//
+	// The key is available to the handler as RouteData.CacheKey and is used for responses
+	// with a CacheTime but no CacheKey
+	CacheKeyFunc func(d RouteData, r *http.Request) string
+
+	// Semaphore guarding the handler when MaxConcurrency is set, created by Route()
+	sem chan struct{}
 }
 
 type RouteData struct {
-	Context context.Context
-	Auth    AuthData
-	Props   map[string]string // Stores additional properties
+	Context  context.Context
+	Auth     AuthData
+	Props    map[string]string // Stores additional properties
+	CacheKey string            // Cache key from the routes CacheKeyFunc, empty if not set
+
+	// The path params of the request keyed by name (e.g. id for /bots/{id}), these are validated against the docs by Route()
+	PathParams map[string]string
+}
+
+// Returns the path params chi matched for the request, excluding the catch-all (*) param
+func pathParams(req *http.Request) map[string]string {
+	params := map[string]string{}
+
+	rctx := chi.RouteContext(req.Context())
+
+	if rctx == nil {
+		return params
+	}
+
+	for i, key := range rctx.URLParams.Keys {
+		if key == "*" || i >= len(rctx.URLParams.Values) {
+			continue
+		}
+
+		params[key] = rctx.URLParams.Values[i]
+	}
+
+	return params
 }
 
 type Router interface {
@@ -178,52 +304,70 @@ type Router interface {
 }
 
 func (r Route) String() string {
-	return r.Method.String() + " " + r.Pattern + " (" + r.OpId + ")"
+	// Unknown methods are formatted by value so misconfigured routes can still be described
+	method := "Method(" + strconv.Itoa(int(r.Method)) + ")"
+
+	if r.Method.valid() {
+		method = r.Method.String()
+	}
+
+	return method + " " + r.Pattern + " (" + r.OpId + ")"
 }
 
-func (r Route) Route(ro Router) {
+// Validate checks the route for misconfiguration, returning the same errors Route would panic with
+//
+// This calls Docs but not Setup, so Docs must not depend on Setup having been run
+func (r Route) Validate() error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+
+	return r.validateDocs(r.Docs())
+}
+
+// Checks that don't need the docs of the route
+func (r Route) validate() error {
 	if r.OpId == "" {
-		panic("OpId is empty: " + r.String())
+		return errors.New("OpId is empty: " + r.String())
 	}
 
 	if r.Handler == nil {
-		panic("Handler is nil: " + r.String())
+		return errors.New("Handler is nil: " + r.String())
 	}
 
 	if r.Docs == nil {
-		panic("Docs is nil: " + r.String())
+		return errors.New("Docs is nil: " + r.String())
 	}
 
 	if r.Pattern == "" {
-		panic("Pattern is empty: " + r.String())
+		return errors.New("Pattern is empty: " + r.String())
 	}
 
 	if State.InitData.Tag == "" {
-		panic("CurrentTag is empty: " + r.String())
+		return errors.New("CurrentTag is empty: " + r.String())
 	}
 
-	if r.Setup != nil {
-		r.Setup()
+	if !r.Method.valid() {
+		return errors.New("Unknown method for route: " + r.String())
 	}
 
-	docsObj := r.Docs()
-
-	docsObj.Pattern = r.Pattern
-	docsObj.OpId = r.OpId
-	docsObj.Method = r.Method.String()
-	docsObj.Tags = []string{State.InitData.Tag}
-	docsObj.AuthType = []string{}
-
 	for _, auth := range r.Auth {
-		t, ok := State.AuthTypeMap[auth.Type]
-
-		if !ok {
-			panic("Invalid auth type: " + auth.Type)
+		if _, ok := State.AuthTypeMap[auth.Type]; !ok {
+			return errors.New("Invalid auth type: " + auth.Type)
 		}
+	}
 
-		docsObj.AuthType = append(docsObj.AuthType, t)
+	for status, example := range r.Examples {
+		if _, err := State.JSON.Marshal(example); err != nil {
+			return errors.New("Failed to marshal example for status " + strconv.Itoa(status) + ": " + r.String())
+		}
 	}
 
+	return nil
+}
+
+// Checks the params of the docs match the pattern
+func (r Route) validateDocs(docsObj *docs.Doc) error {
 	// Count the number of { and } in the pattern
 	brStart := strings.Count(r.Pattern, "{")
 	brEnd := strings.Count(r.Pattern, "}")
@@ -232,7 +376,7 @@ func (r Route) Route(ro Router) {
 
 	for _, param := range docsObj.Params {
 		if param.In == "" || param.Name == "" || param.Schema == nil {
-			panic("Param is missing required fields: " + r.String())
+			return errors.New("Param is missing required fields: " + r.String())
 		}
 
 		if param.In == "path" {
@@ -246,28 +390,81 @@ func (r Route) Route(ro Router) {
 			if strings.HasPrefix(param, "{") && strings.HasSuffix(param, "}") {
 				patternParams = append(patternParams, param[1:len(param)-1])
 			} else if strings.Contains(param, "{") || strings.Contains(param, "}") {
-				panic("{ and } in pattern but does not start with it " + r.String())
+				return errors.New("{ and } in pattern but does not start with it " + r.String())
 			}
 		}
 	}
 
 	if brStart != brEnd {
-		panic("Mismatched { and } in pattern: " + r.String())
+		return errors.New("Mismatched { and } in pattern: " + r.String())
 	}
 
 	if brStart != len(pathParams) {
-		panic("Mismatched number of params and { in pattern: " + r.String())
+		return errors.New("Mismatched number of params and { in pattern: " + r.String())
 	}
 
 	if !r.DisablePathSlashCheck {
 		if !slices.Equal(patternParams, pathParams) {
-			panic("Mismatched params in pattern and docs: " + r.String())
+			return errors.New("Mismatched params in pattern and docs: " + r.String())
+		}
+	}
+
+	return nil
+}
+
+// Route registers the route on the router, panicking if the route is misconfigured (see Validate)
+func (r Route) Route(ro Router) {
+	if err := r.validate(); err != nil {
+		panic(err.Error())
+	}
+
+	if r.Setup != nil {
+		r.Setup()
+	}
+
+	docsObj := r.Docs()
+
+	if err := r.validateDocs(docsObj); err != nil {
+		panic(err.Error())
+	}
+
+	docsObj.Pattern = r.Pattern
+	docsObj.OpId = r.OpId
+	docsObj.Method = r.Method.String()
+	docsObj.Tags = []string{State.InitData.Tag}
+	docsObj.AuthType = []string{}
+	docsObj.AuthOptional = r.AuthOptional
+
+	if r.RespType != nil {
+		docsObj.Resp = r.RespType
+	}
+
+	for _, auth := range r.Auth {
+		docsObj.AuthType = append(docsObj.AuthType, State.AuthTypeMap[auth.Type])
+	}
+
+	if len(r.Examples) > 0 {
+		docsObj.Examples = map[int]any{}
+
+		for status, example := range r.Examples {
+			bytes, err := State.JSON.Marshal(example)
+
+			if err != nil {
+				panic("Failed to marshal example for status " + strconv.Itoa(status) + ": " + r.String())
+			}
+
+			// Keep the example exactly as it would be sent by respond()
+			docsObj.Examples[status] = json.RawMessage(bytes)
 		}
 	}
 
 	// Add the path params to the docs
 	docs.Route(docsObj)
 
+	if r.MaxConcurrency > 0 {
+		r.sem = make(chan struct{}, r.MaxConcurrency)
+	}
+
 	switch r.Method {
 	case GET:
 		ro.Get(r.Pattern, func(w http.ResponseWriter, req *http.Request) {
@@ -298,7 +495,7 @@ func (r Route) Route(ro Router) {
 	}
 }
 
-func respond(ctx context.Context, w http.ResponseWriter, data chan HttpResponse) {
+func respond(ctx context.Context, w http.ResponseWriter, req *http.Request, data chan HttpResponse) {
 	select {
 	case <-ctx.Done():
 		return
@@ -306,6 +503,7 @@ func respond(ctx context.Context, w http.ResponseWriter, data chan HttpResponse)
 		if !ok {
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte(State.Constants.InternalServerError))
+			return
 		}
 
 		if msg.Redirect != "" {
@@ -323,8 +521,10 @@ func respond(ctx context.Context, w http.ResponseWriter, data chan HttpResponse)
 			}
 		}
 
+		var body []byte
+
 		if msg.Json != nil {
-			bytes, err := Json.Marshal(msg.Json)
+			bytes, err := State.JSON.Marshal(msg.Json)
 
 			if err != nil {
 				State.Logger.Error("[uapi.respond] Failed to unmarshal JSON response", zap.Error(err), zap.Int("size", len(msg.Data)))
@@ -333,31 +533,86 @@ func respond(ctx context.Context, w http.ResponseWriter, data chan HttpResponse)
 				return
 			}
 
-			// JSON needs this explicitly to avoid calling WriteHeader twice
-			if msg.Status == 0 {
-				w.WriteHeader(http.StatusOK)
-			} else {
-				w.WriteHeader(msg.Status)
-			}
+			body = bytes
+		}
 
-			w.Write(bytes)
+		body = append(body, msg.Bytes...)
+		body = append(body, msg.Data...)
+
+		cacheResponse(ctx, w, msg, body)
+
+		body = compressBody(w, req, body)
+
+		// Trailers must be announced before the header is written
+		for k := range msg.Trailers {
+			w.Header().Add("Trailer", k)
 		}
 
 		if msg.Status == 0 {
-			w.WriteHeader(http.StatusOK)
-		} else {
-			w.WriteHeader(msg.Status)
+			msg.Status = http.StatusOK
 		}
 
-		if len(msg.Bytes) > 0 {
-			w.Write(msg.Bytes)
+		// The body is fully buffered, so set its length instead of relying on chunked encoding
+		//
+		// Trailers can only be sent with chunked encoding
+		if len(msg.Trailers) == 0 && bodyAllowed(msg.Status) {
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+
+		w.WriteHeader(msg.Status)
+
+		// HEAD responses carry the headers (including Content-Length) of the GET response but no body
+		if req.Method != http.MethodHead {
+			w.Write(body)
+		}
+
+		for k, v := range msg.Trailers {
+			w.Header().Set(k, v)
 		}
 
-		w.Write([]byte(msg.Data))
 		return
 	}
 }
 
+// Returns whether a response with the status may have a body (and so a Content-Length), see RFC 7230 section 3.3.2
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// Compresses the body with the best compressor accepted by the client, setting the relevant headers
+//
+// Returns the body unchanged if it is too small, already encoded or no compressor is accepted
+func compressBody(w http.ResponseWriter, req *http.Request, body []byte) []byte {
+	if len(body) < CompressionMinSize || w.Header().Get("Content-Encoding") != "" {
+		return body
+	}
+
+	if len(State.Compressors) == 0 {
+		return body
+	}
+
+	// The response depends on Accept-Encoding even if this client gets it uncompressed
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	compressor := negotiateCompressor(req, State.Compressors)
+
+	if compressor == nil {
+		return body
+	}
+
+	compressed, err := compressor.Compress(body)
+
+	if err != nil {
+		State.Logger.Error("[uapi.respond] Failed to compress response", zap.Error(err), zap.String("encoding", compressor.Encoding()))
+		return body
+	}
+
+	w.Header().Set("Content-Encoding", compressor.Encoding())
+	w.Header().Del("Content-Length")
+
+	return compressed
+}
+
 type HttpResponse struct {
 	// Data is the data to be sent to the client
 	Data string
@@ -371,6 +626,18 @@ type HttpResponse struct {
 	Status int
 	// Redirect to a URL
 	Redirect string
+	// If set, the response body is cached in State.Redis under this key for CacheTime, see FromCache
+	//
+	// X-Cache is set to MISS on fresh responses and HIT on responses from FromCache
+	CacheKey string
+	// How long to cache the response for, the response is not cached if zero
+	CacheTime time.Duration
+
+	// Trailers to send after the body, ignored by clients/protocols that don't support them
+	Trailers map[string]string
+
+	// Whether the response was returned from FromCache
+	cached bool
 }
 
 func CompileValidationErrors(payload any) map[string]string {
@@ -378,6 +645,10 @@ func CompileValidationErrors(payload any) map[string]string {
 
 	structType := reflect.TypeOf(payload)
 
+	for structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+
 	for _, f := range reflect.VisibleFields(structType) {
 		errors[f.Name] = f.Tag.Get("msg")
 
@@ -391,8 +662,24 @@ func CompileValidationErrors(payload any) map[string]string {
 	return errors
 }
 
+// Returns the path of the field that failed validation relative to the validated struct, e.g. Items[2].Name
+//
+// If the validator has a tag name function registered (e.g. for json tags), those names are used
+func validationErrorPath(err validator.FieldError) string {
+	ns := err.Namespace()
+
+	// Strip the name of the validated struct itself
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+
+	return ns
+}
+
 func ValidatorErrorResponse(compiled map[string]string, v validator.ValidationErrors) HttpResponse {
 	var errors = make(map[string]string)
+	var fields = make(map[string][]string)
+	var errList = make([]ValidationError, 0, len(v))
 
 	firstError := ""
 
@@ -416,7 +703,30 @@ func ValidatorErrorResponse(compiled map[string]string, v validator.ValidationEr
 			firstError = errorMsg
 		}
 
-		errors[err.StructField()] = errorMsg
+		key := validationErrorPath(err)
+
+		if State.LegacyValidationErrors {
+			key = err.StructField()
+		}
+
+		if _, ok := errors[key]; !ok {
+			errors[key] = errorMsg
+		}
+
+		fields[key] = append(fields[key], errorMsg)
+
+		errList = append(errList, ValidationError{
+			Field:   validationErrorPath(err),
+			Tag:     err.Tag(),
+			Message: errorMsg,
+		})
+	}
+
+	if responder, ok := State.DefaultResponder.(UAPIValidationResponder); ok {
+		return HttpResponse{
+			Status: http.StatusBadRequest,
+			Json:   responder.NewValidationError(firstError, fields, errList),
+		}
 	}
 
 	return HttpResponse{
@@ -425,6 +735,26 @@ func ValidatorErrorResponse(compiled map[string]string, v validator.ValidationEr
 	}
 }
 
+// Validates payload using State.Validator, returning the standard validation error response on failure
+func Validate(payload any) (HttpResponse, bool) {
+	err := State.Validator.Struct(payload)
+
+	if err == nil {
+		return HttpResponse{}, true
+	}
+
+	var verrs validator.ValidationErrors
+
+	if errors.As(err, &verrs) {
+		return ValidatorErrorResponse(CompileValidationErrors(payload), verrs), false
+	}
+
+	return HttpResponse{
+		Status: http.StatusBadRequest,
+		Json:   State.DefaultResponder.New(err.Error(), nil),
+	}, false
+}
+
 // Creates a default HTTP response based on the status code
 // 200 is treated as 204 No Content
 func DefaultResponse(statusCode int) HttpResponse {
@@ -471,11 +801,64 @@ func DefaultResponse(statusCode int) HttpResponse {
 	}
 }
 
+// Takes a slot from the routes semaphore, waiting up to MaxConcurrencyWait
+func (r Route) acquire(ctx context.Context) bool {
+	select {
+	case r.sem <- struct{}{}:
+		return true
+	default:
+	}
+
+	if r.MaxConcurrencyWait <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(r.MaxConcurrencyWait)
+	defer timer.Stop()
+
+	select {
+	case r.sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
 func handle(r Route, w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
-	resp := make(chan HttpResponse)
+
+	// Buffered so the handler goroutine never blocks (and holds its semaphore slot) if the client goes away
+	resp := make(chan HttpResponse, 1)
+
+	if Draining() {
+		drainResp := make(chan HttpResponse, 1)
+		drainResp <- drainingResponse()
+		respond(ctx, w, req, drainResp)
+		return
+	}
+
+	if r.sem != nil && !r.acquire(ctx) {
+		busyResp := make(chan HttpResponse, 1)
+		busyResp <- HttpResponse{
+			Status: http.StatusServiceUnavailable,
+			Json:   State.DefaultResponder.New("Too many concurrent requests to this endpoint, please try again later", nil),
+			Headers: map[string]string{
+				"Retry-After": "1",
+			},
+		}
+		respond(ctx, w, req, busyResp)
+		return
+	}
 
 	go func() {
+		if r.sem != nil {
+			defer func() {
+				<-r.sem
+			}()
+		}
+
 		defer func() {
 			err := recover()
 
@@ -496,8 +879,9 @@ func handle(r Route, w http.ResponseWriter, req *http.Request) {
 		}
 
 		rd := &RouteData{
-			Context: ctx,
-			Auth:    authData,
+			Context:    ctx,
+			Auth:       authData,
+			PathParams: pathParams(req),
 		}
 
 		if State.RouteDataMiddleware != nil {
@@ -513,19 +897,144 @@ func handle(r Route, w http.ResponseWriter, req *http.Request) {
 			}
 		}
 
-		resp <- r.Handler(*rd, req)
+		if r.CacheKeyFunc != nil {
+			rd.CacheKey = r.CacheKeyFunc(*rd, req)
+		}
+
+		hresp := r.Handler(*rd, req)
+
+		if hresp.CacheKey == "" && hresp.CacheTime > 0 {
+			hresp.CacheKey = rd.CacheKey
+		}
+
+		resp <- hresp
 	}()
 
-	respond(ctx, w, resp)
+	respond(ctx, w, req, resp)
+}
+
+// Limits the request body to State.MaxBodyBytes if set
+func limitBody(r *http.Request) io.Reader {
+	if State.MaxBodyBytes > 0 {
+		return http.MaxBytesReader(nil, r.Body, State.MaxBodyBytes)
+	}
+
+	return r.Body
+}
+
+// Returns a 413 response if err is due to the body exceeding State.MaxBodyBytes
+func bodyTooLarge(err error) (HttpResponse, bool) {
+	var maxBytesErr *http.MaxBytesError
+
+	if !errors.As(err, &maxBytesErr) {
+		return HttpResponse{}, false
+	}
+
+	return HttpResponse{
+		Status: http.StatusRequestEntityTooLarge,
+		Json: State.DefaultResponder.New("Request body too large", map[string]string{
+			"limit": strconv.FormatInt(maxBytesErr.Limit, 10),
+		}),
+	}, true
+}
+
+// Tracks the nesting depth of JSON as it is read, without fully parsing it
+type depthScanner struct {
+	max      int
+	depth    int
+	inString bool
+	escaped  bool
+	exceeded bool
+}
+
+// Scans the next chunk of JSON, returning false once the depth exceeds max
+func (s *depthScanner) scan(p []byte) bool {
+	for _, c := range p {
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+			}
+
+			continue
+		}
+
+		switch c {
+		case '"':
+			s.inString = true
+		case '{', '[':
+			s.depth++
+
+			if s.depth > s.max {
+				s.exceeded = true
+				return false
+			}
+		case '}', ']':
+			s.depth--
+		}
+	}
+
+	return true
+}
+
+var errJSONTooDeep = errors.New("json body exceeds the maximum depth")
+
+// Stops reading once the JSON read so far exceeds the maximum depth
+type depthReader struct {
+	r       io.Reader
+	scanner *depthScanner
+}
+
+func (d *depthReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+
+	// Drop the chunk so the decoder never sees the over-nested JSON
+	if !d.scanner.scan(p[:n]) {
+		return 0, errJSONTooDeep
+	}
+
+	return n, err
+}
+
+// Returns a 400 response if the JSON in body is nested deeper than State.MaxJSONDepth
+func checkJSONDepth(body []byte) (HttpResponse, bool) {
+	if State.MaxJSONDepth <= 0 {
+		return HttpResponse{}, true
+	}
+
+	s := &depthScanner{max: State.MaxJSONDepth}
+
+	if s.scan(body) {
+		return HttpResponse{}, true
+	}
+
+	return jsonTooDeep(), false
+}
+
+func jsonTooDeep() HttpResponse {
+	return HttpResponse{
+		Status: http.StatusBadRequest,
+		Json: State.DefaultResponder.New("JSON body is too deeply nested", map[string]string{
+			"max_depth": strconv.Itoa(State.MaxJSONDepth),
+		}),
+	}
 }
 
 // Read body
 func marshalReq(r *http.Request, dst interface{}) (resp HttpResponse, ok bool) {
 	defer r.Body.Close()
 
-	bodyBytes, err := io.ReadAll(r.Body)
+	bodyBytes, err := io.ReadAll(limitBody(r))
 
 	if err != nil {
+		if resp, ok := bodyTooLarge(err); ok {
+			return resp, false
+		}
+
 		State.Logger.Error("[uapi/marshalReq] Failed to read body", zap.Error(err), zap.Int("size", len(bodyBytes)))
 		return DefaultResponse(http.StatusInternalServerError), false
 	}
@@ -537,7 +1046,11 @@ func marshalReq(r *http.Request, dst interface{}) (resp HttpResponse, ok bool) {
 		}, false
 	}
 
-	err = Json.Unmarshal(bodyBytes, &dst)
+	if resp, ok := checkJSONDepth(bodyBytes); !ok {
+		return resp, false
+	}
+
+	err = State.JSON.Unmarshal(bodyBytes, &dst)
 
 	if err != nil {
 		State.Logger.Error("[uapi/marshalReq] Failed to unmarshal JSON", zap.Error(err), zap.Int("size", len(bodyBytes)))
@@ -556,6 +1069,52 @@ func MarshalReq(r *http.Request, dst any) (resp HttpResponse, ok bool) {
 	return marshalReq(r, dst)
 }
 
+// DecodeStream decodes the request body into dst without buffering the whole body first
+//
+// Useful for large bodies, respects State.MaxBodyBytes and returns the same errors as MarshalReq
+func DecodeStream(r *http.Request, dst any) (resp HttpResponse, ok bool) {
+	defer r.Body.Close()
+
+	body := limitBody(r)
+
+	var scanner *depthScanner
+
+	if State.MaxJSONDepth > 0 {
+		scanner = &depthScanner{max: State.MaxJSONDepth}
+		body = &depthReader{r: body, scanner: scanner}
+	}
+
+	err := State.JSON.NewDecoder(body).Decode(dst)
+
+	if err != nil {
+		if resp, ok := bodyTooLarge(err); ok {
+			return resp, false
+		}
+
+		// The decoder may not wrap read errors, so check the scanner directly
+		if scanner != nil && scanner.exceeded {
+			return jsonTooDeep(), false
+		}
+
+		if errors.Is(err, io.EOF) {
+			return HttpResponse{
+				Status: http.StatusBadRequest,
+				Json:   State.Constants.BodyRequired,
+			}, false
+		}
+
+		State.Logger.Error("[uapi/DecodeStream] Failed to decode JSON", zap.Error(err))
+		return HttpResponse{
+			Status: http.StatusBadRequest,
+			Json: State.DefaultResponder.New("Invalid JSON", map[string]string{
+				"error": err.Error(),
+			}),
+		}, false
+	}
+
+	return HttpResponse{}, true
+}
+
 func MarshalReqWithHeaders(r *http.Request, dst any, headers map[string]string) (resp HttpResponse, ok bool) {
 	resp, err := marshalReq(r, dst)
 
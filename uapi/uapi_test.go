@@ -0,0 +1,146 @@
+package uapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	docs "github.com/topicbotlist/eureka-port/doclib"
+	"go.uber.org/zap"
+)
+
+type testResponder struct{}
+
+func (testResponder) New(msg string, ctx map[string]string) any {
+	return map[string]any{"message": msg, "context": ctx}
+}
+
+func setupTestState(t *testing.T) {
+	t.Helper()
+
+	SetupState(UAPIState{
+		Logger:           zap.NewNop(),
+		Constants:        &UAPIConstants{BodyRequired: "body required", InternalServerError: "internal server error"},
+		DefaultResponder: testResponder{},
+		AuthTypeMap:      map[string]string{"user": "User"},
+	})
+
+	State.SetCurrentTag("test")
+}
+
+var stringSchema = map[string]string{"type": "string"}
+
+func validRoute() Route {
+	return Route{
+		Method:  GET,
+		Pattern: "/users/{id}",
+		OpId:    "get_user",
+		Handler: func(d RouteData, r *http.Request) HttpResponse { return HttpResponse{} },
+		Docs: func() *docs.Doc {
+			return &docs.Doc{
+				Params: []docs.Parameter{{Name: "id", In: "path", Schema: stringSchema}},
+			}
+		},
+	}
+}
+
+func TestRouteValidate(t *testing.T) {
+	setupTestState(t)
+
+	if err := validRoute().Validate(); err != nil {
+		t.Fatalf("valid route failed validation: %s", err)
+	}
+
+	docsWith := func(params ...docs.Parameter) func() *docs.Doc {
+		return func() *docs.Doc { return &docs.Doc{Params: params} }
+	}
+
+	idParam := docs.Parameter{Name: "id", In: "path", Schema: stringSchema}
+
+	tests := []struct {
+		name   string
+		modify func(r *Route)
+		want   string
+	}{
+		{"empty op id", func(r *Route) { r.OpId = "" }, "OpId is empty"},
+		{"nil handler", func(r *Route) { r.Handler = nil }, "Handler is nil"},
+		{"nil docs", func(r *Route) { r.Docs = nil }, "Docs is nil"},
+		{"empty pattern", func(r *Route) { r.Pattern = "" }, "Pattern is empty"},
+		{"invalid method", func(r *Route) { r.Method = Method(42) }, "Unknown method for route: Method(42)"},
+		{"invalid method and op id", func(r *Route) { r.Method, r.OpId = Method(-1), "" }, "OpId is empty"},
+		{"invalid auth type", func(r *Route) { r.Auth = []AuthType{{Type: "bot"}} }, "Invalid auth type: bot"},
+		{"unmarshalable example", func(r *Route) { r.Examples = map[int]any{200: make(chan int)} }, "Failed to marshal example for status 200"},
+		{"incomplete param", func(r *Route) { r.Docs = docsWith(docs.Parameter{Name: "id", In: "path"}) }, "Param is missing required fields"},
+		{"brace inside segment", func(r *Route) { r.Pattern = "/users/x{id}" }, "{ and } in pattern but does not start with it"},
+		{"mismatched braces", func(r *Route) { r.Pattern, r.DisablePathSlashCheck = "/users/{id", true }, "Mismatched { and } in pattern"},
+		{"missing path param", func(r *Route) { r.Docs = docsWith() }, "Mismatched number of params and { in pattern"},
+		{"misnamed path param", func(r *Route) {
+			r.Docs = docsWith(docs.Parameter{Name: "user_id", In: "path", Schema: stringSchema})
+		}, "Mismatched params in pattern and docs"},
+		{"extra path param", func(r *Route) { r.Docs = docsWith(idParam, idParam) }, "Mismatched number of params and { in pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := validRoute()
+			tt.modify(&r)
+
+			err := r.Validate()
+
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.want)
+			}
+
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error containing %q, got %q", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRouteValidateMissingTag(t *testing.T) {
+	setupTestState(t)
+	State.SetCurrentTag("")
+
+	err := validRoute().Validate()
+
+	if err == nil || !strings.Contains(err.Error(), "CurrentTag is empty") {
+		t.Fatalf("expected CurrentTag error, got %v", err)
+	}
+}
+
+func TestRouteStringInvalidMethod(t *testing.T) {
+	r := validRoute()
+	r.Method = Method(7)
+
+	if got, want := r.String(), "Method(7) /users/{id} (get_user)"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	setupTestState(t)
+	State.MaxBodyBytes = 16
+
+	decoders := map[string]func(r *http.Request, dst any) (HttpResponse, bool){
+		"MarshalReq":   MarshalReq,
+		"DecodeStream": DecodeStream,
+	}
+
+	for name, decode := range decoders {
+		var dst map[string]string
+
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a": "`+strings.Repeat("a", 32)+`"}`))
+
+		if resp, ok := decode(r, &dst); ok || resp.Status != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s: oversized body got status %d, want 413", name, resp.Status)
+		}
+
+		r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"a": "b"}`))
+
+		if resp, ok := decode(r, &dst); !ok || dst["a"] != "b" {
+			t.Errorf("%s: body within the limit was rejected: %+v", name, resp)
+		}
+	}
+}
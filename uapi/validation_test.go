@@ -0,0 +1,241 @@
+package uapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type testValidationResponder struct {
+	testResponder
+}
+
+type testValidationError struct {
+	Message string
+	Fields  map[string][]string
+	Errors  []ValidationError
+}
+
+func (testValidationResponder) NewValidationError(msg string, fields map[string][]string, errs []ValidationError) any {
+	return testValidationError{Message: msg, Fields: fields, Errors: errs}
+}
+
+type signup struct {
+	Username string `validate:"required,alphanum" msg:"Username must be alphanumeric"`
+	Password string `validate:"required,min=8" msg:"Password must be at least 8 characters"`
+	Email    string `validate:"required,email" msg:"Email must be valid"`
+}
+
+// Rejects passwords equal to the username, on top of the field rules
+func signupStructValidation(sl validator.StructLevel) {
+	s := sl.Current().Interface().(signup)
+
+	if s.Password != "" && s.Password == s.Username {
+		sl.ReportError(s.Password, "Password", "Password", "nefield", "Username")
+	}
+}
+
+func setupValidationState(t *testing.T) {
+	t.Helper()
+
+	setupTestState(t)
+
+	State.DefaultResponder = testValidationResponder{}
+	State.Validator = validator.New()
+	State.Validator.RegisterStructValidation(signupStructValidation, signup{})
+}
+
+func validationError(t *testing.T, payload any) testValidationError {
+	t.Helper()
+
+	resp, ok := Validate(payload)
+
+	if ok {
+		t.Fatal("expected validation to fail")
+	}
+
+	if resp.Status != http.StatusBadRequest {
+		t.Fatalf("got status %d, want 400", resp.Status)
+	}
+
+	verr, ok := resp.Json.(testValidationError)
+
+	if !ok {
+		t.Fatalf("expected a validation error response, got %T", resp.Json)
+	}
+
+	return verr
+}
+
+func TestValidateFieldFailingTwoRules(t *testing.T) {
+	setupValidationState(t)
+
+	verr := validationError(t, signup{Username: "short", Password: "short", Email: "a@example.com"})
+
+	want := []string{"Password must be at least 8 characters [min]", "Password must be at least 8 characters [nefield]"}
+	got := verr.Fields["Password"]
+
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	if verr.Message != want[0] {
+		t.Fatalf("got message %q, want the first failure %q", verr.Message, want[0])
+	}
+
+	if len(verr.Errors) != 2 || verr.Errors[0].Tag != "min" || verr.Errors[1].Tag != "nefield" {
+		t.Fatalf("unexpected error list %+v", verr.Errors)
+	}
+}
+
+func TestValidateMultipleFieldsFailing(t *testing.T) {
+	setupValidationState(t)
+
+	verr := validationError(t, signup{Username: "not valid!", Password: "", Email: "nope"})
+
+	want := map[string]string{
+		"Username": "Username must be alphanumeric [alphanum]",
+		"Password": "Password must be at least 8 characters [required]",
+		"Email":    "Email must be valid [email]",
+	}
+
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("got fields %v, want %v", verr.Fields, want)
+	}
+
+	for field, msg := range want {
+		if got := verr.Fields[field]; len(got) != 1 || got[0] != msg {
+			t.Errorf("%s: got %q, want [%q]", field, got, msg)
+		}
+	}
+
+	if len(verr.Errors) != 3 {
+		t.Fatalf("got %d errors, want 3", len(verr.Errors))
+	}
+
+	if verr.Message != want["Username"] {
+		t.Fatalf("got message %q, want the first failure", verr.Message)
+	}
+}
+
+func TestValidateLegacyResponderKeepsFirstFailure(t *testing.T) {
+	setupValidationState(t)
+	State.DefaultResponder = testResponder{}
+
+	resp, ok := Validate(signup{Username: "short", Password: "short", Email: "a@example.com"})
+
+	if ok {
+		t.Fatal("expected validation to fail")
+	}
+
+	ctx := resp.Json.(map[string]any)["context"].(map[string]string)
+
+	if got := ctx["Password"]; got != "Password must be at least 8 characters [min]" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestValidatePassing(t *testing.T) {
+	setupValidationState(t)
+
+	if resp, ok := Validate(signup{Username: "octocat", Password: "hunter22", Email: "octocat@example.com"}); !ok {
+		t.Fatalf("valid payload failed validation: %+v", resp)
+	}
+}
+
+func TestValidateUsesInjectedValidator(t *testing.T) {
+	setupValidationState(t)
+
+	// Passes every field rule, only the struct validation registered on State.Validator rejects it
+	payload := signup{Username: "octocat123", Password: "octocat123", Email: "octocat@example.com"}
+
+	verr := validationError(t, payload)
+
+	if len(verr.Errors) != 1 || verr.Errors[0].Tag != "nefield" {
+		t.Fatalf("unexpected error list %+v", verr.Errors)
+	}
+
+	// A validator without the registration accepts it
+	State.Validator = validator.New()
+
+	if resp, ok := Validate(payload); !ok {
+		t.Fatalf("payload failed validation without the struct validation: %+v", resp)
+	}
+}
+
+type orderItem struct {
+	Name string `validate:"required"`
+}
+
+type orderAddress struct {
+	City string `validate:"required"`
+}
+
+type order struct {
+	Reference string       `validate:"required" msg:"Reference is required"`
+	Tags      []string     `validate:"dive,alphanum" amsg:"Tags must be alphanumeric"`
+	Items     []orderItem  `validate:"dive"`
+	Address   orderAddress `validate:"required"`
+}
+
+func validOrder() order {
+	return order{
+		Reference: "ord-1",
+		Tags:      []string{"gift", "fragile"},
+		Items:     []orderItem{{Name: "mug"}, {Name: "plate"}, {Name: "bowl"}},
+		Address:   orderAddress{City: "Lisbon"},
+	}
+}
+
+func TestValidationErrorPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(o *order)
+		path   string
+		legacy string
+		msg    string
+	}{
+		{"top level field", func(o *order) { o.Reference = "" }, "Reference", "Reference", "Reference is required [required]"},
+		{"array element", func(o *order) { o.Tags[1] = "not ok" }, "Tags[1]", "Tags[1]", "Tags must be alphanumeric [alphanum]"},
+		{"struct in array", func(o *order) { o.Items[2].Name = "" }, "Items[2].Name", "Name", ""},
+		{"nested struct", func(o *order) { o.Address.City = "" }, "Address.City", "City", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupValidationState(t)
+
+			o := validOrder()
+			tt.modify(&o)
+
+			verr := validationError(t, o)
+
+			if len(verr.Errors) != 1 || verr.Errors[0].Field != tt.path {
+				t.Fatalf("got errors %+v, want a single error at %s", verr.Errors, tt.path)
+			}
+
+			if _, ok := verr.Fields[tt.path]; !ok {
+				t.Fatalf("got fields %v, want %s", verr.Fields, tt.path)
+			}
+
+			if tt.msg != "" && verr.Errors[0].Message != tt.msg {
+				t.Fatalf("got message %q, want %q", verr.Errors[0].Message, tt.msg)
+			}
+
+			// Legacy mode keys by struct field name but keeps the path in the error list
+			State.LegacyValidationErrors = true
+			defer func() { State.LegacyValidationErrors = false }()
+
+			verr = validationError(t, o)
+
+			if _, ok := verr.Fields[tt.legacy]; !ok {
+				t.Fatalf("legacy mode: got fields %v, want %s", verr.Fields, tt.legacy)
+			}
+
+			if verr.Errors[0].Field != tt.path {
+				t.Fatalf("legacy mode: got error at %s, want %s", verr.Errors[0].Field, tt.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package zapchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	logger, logs := newTestLogger()
+
+	r := chi.NewRouter()
+	r.Use(LoggerWithOptions(logger, "", Options{Tracing: true, TracerProvider: tp}))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !trace.SpanFromContext(r.Context()).SpanContext().IsValid() {
+			t.Error("handler context has no span")
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/123?full=true", nil)
+	req.Header.Set("User-Agent", "test-agent")
+
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	span := spans[0]
+
+	if span.Name != "GET /users/{id}" || span.SpanKind != trace.SpanKindServer {
+		t.Fatalf("got span %q of kind %s", span.Name, span.SpanKind)
+	}
+
+	if span.Status.Code != codes.Error {
+		t.Fatalf("got span status %v, want an error for a 500", span.Status.Code)
+	}
+
+	attrs := map[attribute.Key]attribute.Value{}
+
+	for _, kv := range span.Attributes {
+		attrs[kv.Key] = kv.Value
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		"http.method":                  attribute.StringValue("GET"),
+		"http.target":                  attribute.StringValue("/users/123?full=true"),
+		"http.route":                   attribute.StringValue("/users/{id}"),
+		"http.scheme":                  attribute.StringValue("http"),
+		"http.user_agent":              attribute.StringValue("test-agent"),
+		"http.status_code":             attribute.IntValue(500),
+		"http.response_content_length": attribute.IntValue(4),
+	}
+
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s: got %v, want %v", k, attrs[k].Emit(), v.Emit())
+		}
+	}
+
+	// The span and the log entry share the request ID
+	entries := logs.All()
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+
+	if reqId := entries[0].ContextMap()["reqId"]; attrs["reqId"].AsString() != reqId {
+		t.Fatalf("span reqId %q does not match logged reqId %v", attrs["reqId"].AsString(), reqId)
+	}
+}
+
+func TestTracingWithoutProvider(t *testing.T) {
+	logger, logs := newTestLogger()
+
+	// The global provider is a no-op unless configured
+	mw := LoggerWithOptions(logger, "", Options{Tracing: true})
+
+	serve(mw(statusHandler(http.StatusOK)), 1)
+
+	if logs.Len() != 1 {
+		t.Fatalf("got %d log entries, want 1", logs.Len())
+	}
+}
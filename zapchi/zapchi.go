@@ -3,18 +3,124 @@ package zapchi
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/topicbotlist/eureka-port/crypto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
+// The instrumentation name used for the tracer
+const tracerName = "github.com/topicbotlist/eureka-port/zapchi"
+
+// Sampling configures log sampling of successful responses, see zapcore.NewSamplerWithOptions
+type Sampling struct {
+	// Tick is the interval over which responses are counted, defaults to 1 second
+	Tick time.Duration
+	// First is the number of successful responses logged per Tick before sampling kicks in
+	First int
+	// Thereafter is the rate successful responses are logged at once First is reached (1 in Thereafter)
+	Thereafter int
+}
+
+// Options for LoggerWithOptions
+type Options struct {
+	// Sampling, if set, samples responses with a status below 400. 4xx and 5xx responses are always logged
+	Sampling *Sampling
+
+	// Fields, if set, returns extra fields to add to each log entry
+	//
+	// Called after the handler has run, so values handlers store in the request context
+	// (e.g. through a pointer set by earlier middleware) are visible
+	Fields func(r *http.Request) []zap.Field
+
+	// SkipPaths are request paths (exact match) that are not logged
+	SkipPaths []string
+
+	// SkipUserAgents are user agent substrings (e.g. of health check probes) that are not logged
+	SkipUserAgents []string
+
+	// OnComplete, if set, is called after each logged request with its status, latency and response size
+	//
+	// Useful for recording metrics (e.g. prometheus histograms) without parsing log lines
+	OnComplete func(r *http.Request, status int, latency time.Duration, size int)
+
+	// Tracing wraps each logged request in an OpenTelemetry span with the standard http.* attributes
+	// and the reqId of its log entry
+	//
+	// A remote parent is extracted from the request headers using the global propagator. Without a
+	// configured tracer provider, the spans are no-ops
+	Tracing bool
+
+	// TracerProvider used when Tracing is set, defaults to the global tracer provider
+	TracerProvider trace.TracerProvider
+}
+
+func (o Options) tracer() trace.Tracer {
+	tp := o.TracerProvider
+
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return tp.Tracer(tracerName)
+}
+
+// Returns the route pattern chi matched, if any
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+
+	return ""
+}
+
+func (o Options) skip(r *http.Request) bool {
+	for _, path := range o.SkipPaths {
+		if r.URL.Path == path {
+			return true
+		}
+	}
+
+	if len(o.SkipUserAgents) > 0 {
+		ua := r.UserAgent()
+
+		for _, skipUa := range o.SkipUserAgents {
+			if strings.Contains(ua, skipUa) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func scheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
 // Logger is a Chi middleware that logs each request recived using
 // the provided unsugared logger
 // Provide a name if you want to set the caller (`.Named()`)
 // otherwise leave blank.
 func Logger(l interface{}, name string) func(next http.Handler) http.Handler {
+	return LoggerWithOptions(l, name, Options{})
+}
+
+// LoggerWithOptions is like Logger but allows customizing the logging behaviour
+func LoggerWithOptions(l interface{}, name string, opts Options) func(next http.Handler) http.Handler {
 	var logger *zap.Logger
 
 	switch l := l.(type) {
@@ -28,14 +134,89 @@ func Logger(l interface{}, name string) func(next http.Handler) http.Handler {
 
 	logger = logger.Named(name)
 
+	successLogger := logger
+
+	if opts.Sampling != nil {
+		tick := opts.Sampling.Tick
+
+		if tick == 0 {
+			tick = time.Second
+		}
+
+		successLogger = logger.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			return zapcore.NewSamplerWithOptions(c, tick, opts.Sampling.First, opts.Sampling.Thereafter)
+		}))
+	}
+
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
+			if opts.skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			reqId := crypto.RandString(12)
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			var span trace.Span
+
+			if opts.Tracing {
+				ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+				ctx, span = opts.tracer().Start(
+					ctx,
+					r.Method,
+					trace.WithSpanKind(trace.SpanKindServer),
+					trace.WithAttributes(
+						attribute.String("http.method", r.Method),
+						attribute.String("http.target", r.URL.RequestURI()),
+						attribute.String("http.scheme", scheme(r)),
+						attribute.String("http.flavor", strings.TrimPrefix(r.Proto, "HTTP/")),
+						attribute.String("http.user_agent", r.UserAgent()),
+						attribute.String("net.host.name", r.Host),
+						attribute.String("reqId", reqId),
+					),
+				)
+
+				r = r.WithContext(ctx)
+			}
+
 			t1 := time.Now()
 			next.ServeHTTP(ww, r)
+			latency := time.Since(t1)
+
+			if span != nil {
+				status := ww.Status()
+
+				// chi only knows the route once it has been matched
+				if route := routePattern(r); route != "" {
+					span.SetName(r.Method + " " + route)
+					span.SetAttributes(attribute.String("http.route", route))
+				}
+
+				span.SetAttributes(
+					attribute.Int("http.status_code", status),
+					attribute.Int("http.response_content_length", ww.BytesWritten()),
+				)
 
-			logger.With(
+				if status >= 500 {
+					span.SetStatus(codes.Error, http.StatusText(status))
+				}
+
+				span.End()
+			}
+
+			if opts.OnComplete != nil {
+				opts.OnComplete(r, ww.Status(), latency, ww.BytesWritten())
+			}
+
+			l := logger
+
+			if ww.Status() < 400 {
+				l = successLogger
+			}
+
+			fields := []zap.Field{
 				zap.Int("status", ww.Status()),
 				zap.String("statusText", http.StatusText(ww.Status())),
 				zap.String("method", r.Method),
@@ -43,10 +224,16 @@ func Logger(l interface{}, name string) func(next http.Handler) http.Handler {
 				zap.String("reqIp", r.RemoteAddr),
 				zap.String("protocol", r.Proto),
 				zap.Int("size", ww.BytesWritten()),
-				zap.String("latency", time.Since(t1).String()),
+				zap.String("latency", latency.String()),
 				zap.String("userAgent", r.UserAgent()),
 				zap.String("reqId", reqId),
-			).Info("Got Request")
+			}
+
+			if opts.Fields != nil {
+				fields = append(fields, opts.Fields(r)...)
+			}
+
+			l.Info("Got Request", fields...)
 		}
 		return http.HandlerFunc(fn)
 	}
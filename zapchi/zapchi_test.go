@@ -0,0 +1,162 @@
+package zapchi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// Returns a logger recording its entries
+func newTestLogger() (*zap.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zap.InfoLevel)
+	return zap.New(core), logs
+}
+
+func statusHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	})
+}
+
+func serve(h http.Handler, n int) {
+	for i := 0; i < n; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+}
+
+func TestSampling(t *testing.T) {
+	logger, logs := newTestLogger()
+
+	mw := LoggerWithOptions(logger, "", Options{
+		Sampling: &Sampling{Tick: time.Minute, First: 5, Thereafter: 10},
+	})
+
+	serve(mw(statusHandler(http.StatusOK)), 105)
+
+	// The first 5, then every 10th of the remaining 100
+	if got := logs.TakeAll(); len(got) != 15 {
+		t.Fatalf("got %d logged 200s, want 15", len(got))
+	}
+
+	serve(mw(statusHandler(http.StatusInternalServerError)), 50)
+
+	if got := logs.TakeAll(); len(got) != 50 {
+		t.Fatalf("got %d logged 500s, want all 50", len(got))
+	}
+}
+
+type userKey struct{}
+
+func TestFields(t *testing.T) {
+	logger, logs := newTestLogger()
+
+	mw := LoggerWithOptions(logger, "", Options{
+		Fields: func(r *http.Request) []zap.Field {
+			return []zap.Field{zap.String("userId", *r.Context().Value(userKey{}).(*string))}
+		},
+	})
+
+	// The user is only known once the handler has authenticated the request
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*r.Context().Value(userKey{}).(*string) = "1234"
+	}))
+
+	var userId string
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), userKey{}, &userId))
+
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	entries := logs.All()
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+
+	if got := entries[0].ContextMap()["userId"]; got != "1234" {
+		t.Fatalf("got userId %v, want 1234", got)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	logger, logs := newTestLogger()
+
+	h := LoggerWithOptions(logger, "", Options{
+		SkipPaths:      []string{"/healthz"},
+		SkipUserAgents: []string{"ELB-HealthChecker"},
+	})(statusHandler(http.StatusOK))
+
+	tests := []struct {
+		path   string
+		ua     string
+		logged bool
+	}{
+		{"/users", "Mozilla/5.0", true},
+		{"/users", "ELB-HealthChecker/2.0", false},
+		{"/random/probe/path", "ELB-HealthChecker/2.0", false},
+		{"/healthz", "Mozilla/5.0", false},
+		{"/healthz/extra", "Mozilla/5.0", true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		r.Header.Set("User-Agent", tt.ua)
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: skipped request was not served", tt.path, tt.ua)
+		}
+
+		if logged := len(logs.TakeAll()) == 1; logged != tt.logged {
+			t.Errorf("%s %s: logged=%v, want %v", tt.path, tt.ua, logged, tt.logged)
+		}
+	}
+}
+
+// Records the arguments of OnComplete
+type completeRecorder struct {
+	calls   int
+	status  int
+	latency time.Duration
+	size    int
+}
+
+func (c *completeRecorder) record(r *http.Request, status int, latency time.Duration, size int) {
+	c.calls++
+	c.status = status
+	c.latency = latency
+	c.size = size
+}
+
+func TestOnComplete(t *testing.T) {
+	logger, _ := newTestLogger()
+	rec := &completeRecorder{}
+
+	h := LoggerWithOptions(logger, "", Options{OnComplete: rec.record})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if rec.calls != 1 {
+		t.Fatalf("OnComplete called %d times, want 1", rec.calls)
+	}
+
+	if rec.status != http.StatusCreated || rec.size != len("created") {
+		t.Fatalf("got status %d and size %d, want 201 and %d", rec.status, rec.size, len("created"))
+	}
+
+	if rec.latency <= 0 {
+		t.Fatalf("got latency %s, want a positive latency", rec.latency)
+	}
+}